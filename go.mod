@@ -2,7 +2,10 @@ module github.com/becheran/go-testreport
 
 go 1.18
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.0
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
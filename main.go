@@ -1,49 +1,295 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/becheran/go-testreport/src/args"
+	"github.com/becheran/go-testreport/src/codeowners"
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/gomod"
+	"github.com/becheran/go-testreport/src/notify"
+	"github.com/becheran/go-testreport/src/plan"
 	"github.com/becheran/go-testreport/src/report"
+	"github.com/becheran/go-testreport/src/rerun"
 )
 
+const (
+	exitTestsFailed    = 1
+	exitError          = 2
+	exitDeliveryFailed = 3
+)
+
+func fatalf(format string, v ...any) {
+	log.Printf(format, v...)
+	os.Exit(exitError)
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "plan" {
+		planShards()
+		return
+	}
+
 	args, err := args.ParseArgs(os.Args, flag.CommandLine)
 	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 	defer args.OutputStream.Close()
 	defer args.InputStream.Close()
 
 	tmp, err := report.GetTemplate(args.TemplateFile)
 	if err != nil {
-		log.Fatalf("Invalid template. %s", err)
+		fatalf("Invalid template. %s", err)
 	}
 
-	result, err := report.ParseTestJson(args.InputStream)
+	input := io.Reader(args.InputStream)
+	var teamCity *format.TeamCityStream
+	if args.TeamCity {
+		teamCity = format.NewTeamCityStream(os.Stdout)
+		input = io.TeeReader(args.InputStream, teamCity)
+	}
+
+	result, err := report.ParseTestJson(input)
 	if err != nil {
-		log.Fatalf("Failed to parse test result %s", err)
+		fatalf("Failed to parse test result %s", err)
+	}
+	if teamCity != nil {
+		if err := teamCity.Close(); err != nil {
+			fatalf("Failed to write TeamCity service messages. %s", err)
+		}
+	}
+	if args.CoverProfile != "" {
+		profile, err := readCoverProfile(args.CoverProfile)
+		if err != nil {
+			fatalf("Failed to read cover profile %s. %s", args.CoverProfile, err)
+		}
+		result.SetCoverProfile(profile)
 	}
 
 	result.Vars = args.EnvArgs
+	if modules, err := gomod.Modules("."); err == nil {
+		result.SetModules(modules, ".")
+	}
+
+	if args.Rerun > 0 {
+		if err := rerun.Rerun(&result, args.Rerun, rerun.GoTest); err != nil {
+			fatalf("Failed to rerun failed tests. %s", err)
+		}
+		if teamCity != nil {
+			if err := teamCity.Reruns(result); err != nil {
+				fatalf("Failed to write TeamCity service messages. %s", err)
+			}
+		}
+	}
+
+	if args.QuarantineFile != "" {
+		quarantine, err := report.LoadQuarantine(args.QuarantineFile)
+		if err != nil {
+			fatalf("Failed to load quarantine list. %s", err)
+		}
+		quarantine.Apply(&result, time.Now())
+	}
+
+	if args.BudgetsFile != "" {
+		budgets, err := report.LoadBudgets(args.BudgetsFile)
+		if err != nil {
+			fatalf("Failed to load budgets. %s", err)
+		}
+		budgets.Apply(&result)
+	}
+
+	if args.CodeOwnersFile != "" {
+		owners, err := codeowners.Load(args.CodeOwnersFile)
+		if err != nil {
+			fatalf("Failed to load code owners. %s", err)
+		}
+		owners.Assign(&result, codeowners.Root(args.CodeOwnersFile))
+	}
+
+	// Filters only select what is reported. The exit policy is evaluated on all tests.
+	unfiltered := result
+	result = args.Filter.Apply(result)
+	result.Sort(args.SortOrder)
+
+	if args.Format != "" {
+		write, _ := format.Get(args.Format)
+		if err := write(result, args.OutputStream, args.FormatOptions); err != nil {
+			fatalf("Failed to write %s output. %s", args.Format, err)
+		}
+	} else if err := report.CreateReport(result, args.OutputStream, tmp); err != nil {
+		fatalf("Failed to create test report. %s", err)
+	}
+
+	if args.GitLabDir != "" {
+		if err := format.WriteGitLab(result, args.GitLabDir); err != nil {
+			fatalf("Failed to write GitLab reports. %s", err)
+		}
+	}
+
+	if args.BadgesDir != "" {
+		if err := format.WriteBadges(result, args.BadgesDir, args.BadgeOptions); err != nil {
+			fatalf("Failed to write badges. %s", err)
+		}
+	}
+
+	// Failed deliveries do not hide the test status. They are logged and only change the exit code if the tests passed.
+	deliveryFailed := false
+	warnf := func(format string, v ...any) {
+		log.Printf("Warning: "+format, v...)
+		deliveryFailed = true
+	}
+
+	if args.OTLPEndpoint != "" {
+		client := &http.Client{Timeout: 30 * time.Second}
+		if err := format.SendOTLP(client, args.OTLPEndpoint, args.OTLPHeaders, result); err != nil {
+			warnf("Failed to send OpenTelemetry trace to %s. %s", args.OTLPEndpoint, err)
+		}
+	}
 
-	if err := report.CreateReport(result, args.OutputStream, tmp); err != nil {
-		log.Fatalf("Failed to create test report. %s", err)
+	if args.Webhook != "" {
+		client := &http.Client{Timeout: 30 * time.Second}
+		write, _ := format.Get(args.WebhookFormat)
+		// The URL of webhooks contains a secret and is not logged
+		if err := format.SendWebhook(client, args.Webhook, write, result); err != nil {
+			warnf("Failed to send %s message to webhook. %s", args.WebhookFormat, err)
+		}
+	}
+
+	var previous *notify.State
+	if args.StateFile != "" {
+		previous, err = notify.LoadState(args.StateFile)
+		if err != nil {
+			fatalf("Failed to load notify state. %s", err)
+		}
 	}
 
-	failed := false
+	if args.NotifyFile != "" {
+		endpoints, err := notify.Load(args.NotifyFile)
+		if err != nil {
+			fatalf("Failed to load notify endpoints. %s", err)
+		}
+		if err := endpoints.Notify(result, previous); err != nil {
+			warnf("Failed to notify endpoints. %s", err)
+		}
+	}
+
+	if args.EmailFile != "" {
+		email, err := notify.LoadEmail(args.EmailFile)
+		if err != nil {
+			fatalf("Failed to load email configuration. %s", err)
+		}
+		if email.On.Matches(result, previous) {
+			if err := email.Send(result); err != nil {
+				warnf("Failed to send email. %s", err)
+			}
+		}
+	}
+
+	if args.StateFile != "" {
+		if err := notify.NewState(result, time.Now()).Save(args.StateFile); err != nil {
+			fatalf("Failed to save notify state. %s", err)
+		}
+	}
+
+	// Keep machine readable output and TeamCity service messages on stdout parsable
+	console := os.Stdout
+	if args.Format != "" || args.TeamCity {
+		console = os.Stderr
+	}
+	failedPackages := 0
 	for _, packRes := range result.PackageResult {
-		fmt.Println(packRes)
-		if packRes.PackageResult == report.FTSFail {
-			failed = true
+		fmt.Fprintln(console, packRes)
+		for _, test := range packRes.Tests {
+			if test.Flaky() {
+				fmt.Fprintf(console, "        flaky: %s passed on rerun %d\n", test.Name, len(test.Attempts))
+			}
+		}
+		if command := packRes.RerunCommand(); command != "" {
+			fmt.Fprintln(console, "        rerun: "+command)
+			failedPackages++
+		}
+	}
+	if failedPackages > 1 {
+		fmt.Fprintln(console, "Rerun all failed tests with: "+result.RerunCommand())
+	}
+
+	if violations := args.ExitPolicy.Violations(unfiltered); len(violations) > 0 {
+		for _, violation := range violations {
+			log.Printf("Test run failed: %s", violation)
 		}
+		os.Exit(exitTestsFailed)
+	}
+	if deliveryFailed {
+		os.Exit(exitDeliveryFailed)
+	}
+}
+
+// readCoverProfile parses the cover profile file.
+func readCoverProfile(pathToFile string) (report.CoverProfile, error) {
+	file, err := os.Open(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return report.ParseCoverProfile(file)
+}
+
+// planShards writes the shards which are planned from past test results.
+func planShards() {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	args, err := args.ParsePlanArgs(os.Args[1:], fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(exitError)
+	}
+	defer args.OutputStream.Close()
+
+	var results []report.Result
+	for _, historyFile := range args.HistoryFiles {
+		file, err := os.Open(historyFile)
+		if err != nil {
+			fatalf("Failed to open test result %s. %s", historyFile, err)
+		}
+		result, err := report.ParseTestJson(file)
+		file.Close()
+		if err != nil {
+			fatalf("Failed to parse test result %s. %s", historyFile, err)
+		}
+		results = append(results, result)
+	}
+
+	var packages []string
+	if args.PackagesFile != "" {
+		in := os.Stdin
+		if args.PackagesFile != "-" {
+			if in, err = os.Open(args.PackagesFile); err != nil {
+				fatalf("Failed to open packages file. %s", err)
+			}
+			defer in.Close()
+		}
+		if packages, err = plan.ReadPackages(in); err != nil {
+			fatalf("Failed to read packages. %s", err)
+		}
+	}
+
+	history := plan.Average(results, packages)
+	if modules, err := gomod.Modules("."); err == nil {
+		history.SetModules(modules, ".")
 	}
 
-	if args.NonZeroExitOnFailure && failed {
-		os.Exit(1)
+	encoder := json.NewEncoder(args.OutputStream)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(plan.New(history, args.Shards)); err != nil {
+		fatalf("Failed to write plan. %s", err)
 	}
 }
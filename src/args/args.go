@@ -6,14 +6,57 @@ import (
 	"io"
 	"os"
 	"strings"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
 )
 
 type Args struct {
-	TemplateFile         string
-	OutputStream         io.WriteCloser
-	InputStream          io.ReadCloser
-	EnvArgs              map[string]string
-	NonZeroExitOnFailure bool
+	TemplateFile   string
+	QuarantineFile string
+	BudgetsFile    string
+	CodeOwnersFile string
+	OutputStream   io.WriteCloser
+	InputStream    io.ReadCloser
+	EnvArgs        map[string]string
+	ExitPolicy     report.ExitPolicy
+	Filter         report.Filter
+	SortOrder      report.SortOrder
+	Rerun          int
+	Format         string
+	FormatOptions  format.Options
+	OTLPEndpoint   string
+	OTLPHeaders    map[string]string
+	TeamCity       bool
+	GitLabDir      string
+	BadgesDir      string
+	BadgeOptions   format.BadgeOptions
+	CoverProfile   string
+	Webhook        string
+	WebhookFormat  string
+	NotifyFile     string
+	EmailFile      string
+	StateFile      string
+}
+
+// PlanArgs are the arguments of the plan command.
+type PlanArgs struct {
+	Shards       int
+	PackagesFile string
+	HistoryFiles []string
+	OutputStream io.WriteCloser
+}
+
+// stringList is a flag which can be set multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 func ParseArgs(cmdArgs []string, fs *flag.FlagSet) (result Args, err error) {
@@ -22,10 +65,43 @@ func ParseArgs(cmdArgs []string, fs *flag.FlagSet) (result Args, err error) {
 		flag.PrintDefaults()
 	}
 
-	var vars, inputFile, outputFile string
+	var vars, runURL, inputFile, outputFile, failOn, statuses, sortOrder string
+	var badgeThresholds, coverageThresholds, columns string
+	var include, exclude, otlpHeaders stringList
 	fs.StringVar(&inputFile, "input", "", "Input json test result file. If not set, stdin will be used")
 	fs.StringVar(&outputFile, "output", "", "Output result file. If not set, stdout will be used")
-	fs.StringVar(&result.TemplateFile, "template", "", "Template file for the report generation. If not set, the default template will be applied")
+	fs.StringVar(&result.TemplateFile, "template", "", "Template file for the report generation or the name of a built-in template: md or tree. If not set, the default md template will be applied")
+	fs.StringVar(&result.Format, "format", "", "Machine readable output format which is written instead of the template: "+strings.Join(format.Names(), ", "))
+	fs.IntVar(&result.FormatOptions.MaxTests, "metrics-max-tests", 0, "Maximum number of tests with duration metrics in the openmetrics format. The slowest tests are kept. If not set, all tests are written")
+	fs.StringVar(&columns, "columns", "", "Comma separated list of columns of the csv and ndjson formats: "+strings.Join(format.ColumnNames(), ", ")+". If not set, all columns are written")
+	fs.StringVar(&result.OTLPEndpoint, "otlp-endpoint", "", "OTLP/HTTP endpoint to which the result is sent as OpenTelemetry trace. For example http://localhost:4318/v1/traces")
+	fs.BoolVar(&result.TeamCity, "teamcity", false, "Write TeamCity service messages to stdout while the test output is read. Reruns are written after the tests finished. Requires -output and the summary is written to stderr")
+	fs.StringVar(&result.GitLabDir, "gitlab", "", "Directory in which the JUnit report "+format.GitLabJUnitFile+" and the Code Quality report "+format.GitLabCodeQualityFile+" for GitLab merge requests are written")
+	fs.StringVar(&result.BadgesDir, "badges", "", "Directory in which the tests badge "+format.TestsBadgeFile+" and the shields.io endpoint "+format.TestsEndpointFile+" are written. "+
+		"If -coverprofile is set, the coverage badge "+format.CoverageBadgeFile+" and "+format.CoverageEndpointFile+" are written too")
+	fs.StringVar(&result.BadgeOptions.Label, "badge-label", format.DefaultBadgeLabel, "Label of the tests badge")
+	fs.StringVar(&result.BadgeOptions.CoverageLabel, "coverage-label", format.DefaultCoverageLabel, "Label of the coverage badge")
+	fs.StringVar(&badgeThresholds, "badge-thresholds", format.DefaultBadgeThresholds, "Pass rates in percent in the form <red>,<yellow> below which the tests badge is red or yellow")
+	fs.StringVar(&coverageThresholds, "coverage-thresholds", format.DefaultCoverageThresholds, "Coverages in percent in the form <red>,<yellow> below which the coverage badge is red or yellow")
+	fs.StringVar(&result.CoverProfile, "coverprofile", "", "Cover profile of go test -coverprofile from which the total coverage is computed. Without profile, only the coverage of the packages is reported and no total coverage or coverage badge is written")
+	fs.StringVar(&result.Webhook, "webhook", "", "URL of an incoming webhook of Slack or Microsoft Teams to which a summary of the result is posted")
+	fs.StringVar(&result.WebhookFormat, "webhook-format", "slack", "Format of the message which is posted to the webhook: slack or teams")
+	fs.StringVar(&result.NotifyFile, "notify", "", "JSON file with HTTP endpoints to which the JSON encoded result is posted")
+	fs.StringVar(&result.EmailFile, "email", "", "JSON file with the SMTP server and the recipients to which an HTML summary of the result is mailed")
+	fs.StringVar(&result.StateFile, "notify-state", "", "JSON file in which the status of the run is stored. The status of the previous run is read from it for the change condition of notify endpoints and the email")
+	fs.Var(&otlpHeaders, "otlp-header", "HTTP header of the OTLP requests in the form <key>=<value>. Can be set multiple times")
+	fs.StringVar(&result.QuarantineFile, "quarantine", "", "JSON file with a list of known flaky tests. Failures of quarantined tests are reported, but do not cause a non zero exit code")
+	fs.StringVar(&result.BudgetsFile, "budgets", "", "JSON file with maximum durations for matching packages and tests")
+	fs.StringVar(&result.CodeOwnersFile, "codeowners", "", "CODEOWNERS file which is used to assign owners to packages and failed tests. Packages are mapped to directories with the go.mod or go.work file of the current directory")
+	fs.IntVar(&result.Rerun, "rerun", 0, "Maximum number of times failed tests are run again with go test. Tests which pass on a rerun are reported as flaky and do not cause a non zero exit code")
+	fs.StringVar(&failOn, "fail-on", "", "Comma separated list of conditions which cause a non zero exit code: failure, failed-tests, skipped, incomplete, budget, pass-rate:<percent>, duration:<duration> or never. "+
+		"If not set, reading from stdin fails on failure and reading from an input file never fails")
+	fs.Var(&include, "include", "Only report packages or tests which match the filter. Can be set multiple times. "+
+		"Filters have the form <kind>:<pattern> with the kinds package, package-regex, test, test-regex and owner. For example -include=\"package:*/api/*\"")
+	fs.Var(&exclude, "exclude", "Do not report packages or tests which match the filter. Can be set multiple times. Uses the same form as -include")
+	fs.StringVar(&statuses, "status", "", "Comma separated list of test states which shall be reported: pass, fail, skip or incomplete. If not set, all tests are reported")
+	fs.StringVar(&sortOrder, "sort", "status", "Order of packages and tests: status (failed and slowest first), name, path, start or duration")
+	fs.StringVar(&runURL, "run-url", "", "URL of the CI run which is linked in chat messages. Available as RunURL variable in templates")
 	fs.StringVar(&vars, "vars", "", "Comma separated list of custom variables which can be used in the template. For example -vars=\"Title:Custom Title\"")
 
 	if err := fs.Parse(cmdArgs[1:]); err != nil {
@@ -35,13 +111,85 @@ func ParseArgs(cmdArgs []string, fs *flag.FlagSet) (result Args, err error) {
 		return Args{}, fmt.Errorf("unexpected arguments: %v", fs.Args())
 	}
 
+	for _, pattern := range include {
+		if err := result.Filter.AddPattern(pattern, true); err != nil {
+			return Args{}, err
+		}
+	}
+	for _, pattern := range exclude {
+		if err := result.Filter.AddPattern(pattern, false); err != nil {
+			return Args{}, err
+		}
+	}
+	if err := result.Filter.AddStatuses(statuses); err != nil {
+		return Args{}, err
+	}
+
+	if result.Format != "" {
+		if _, err := format.Get(result.Format); err != nil {
+			return Args{}, err
+		}
+	}
+
+	if result.TeamCity && result.Format == "teamcity" {
+		return Args{}, fmt.Errorf("teamcity can not be combined with the teamcity format")
+	}
+	if result.TeamCity && outputFile == "" {
+		return Args{}, fmt.Errorf("teamcity writes to stdout and requires an output file for the report")
+	}
+
+	if result.BadgeOptions.Thresholds, err = format.ParseThresholds(badgeThresholds); err != nil {
+		return Args{}, fmt.Errorf("invalid badge-thresholds. %s", err)
+	}
+	if result.BadgeOptions.CoverageThresholds, err = format.ParseThresholds(coverageThresholds); err != nil {
+		return Args{}, fmt.Errorf("invalid coverage-thresholds. %s", err)
+	}
+
+	if result.WebhookFormat != "slack" && result.WebhookFormat != "teams" {
+		return Args{}, fmt.Errorf("unknown webhook format %s. Expected slack or teams", result.WebhookFormat)
+	}
+
+	result.OTLPHeaders = make(map[string]string)
+	for _, header := range otlpHeaders {
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || key == "" {
+			return Args{}, fmt.Errorf("expected OTLP header in the form <key>=<value>, got %s", header)
+		}
+		result.OTLPHeaders[key] = value
+	}
+
+	if result.FormatOptions.Columns, err = format.ParseColumns(columns); err != nil {
+		return Args{}, err
+	}
+
+	if result.FormatOptions.MaxTests < 0 {
+		return Args{}, fmt.Errorf("metrics-max-tests must not be negative")
+	}
+
+	if result.Rerun < 0 {
+		return Args{}, fmt.Errorf("rerun must not be negative")
+	}
+
+	result.SortOrder, err = report.ParseSortOrder(sortOrder)
+	if err != nil {
+		return Args{}, err
+	}
+
+	if failOn != "" {
+		result.ExitPolicy, err = report.ParseExitPolicy(failOn)
+		if err != nil {
+			return Args{}, err
+		}
+	} else if inputFile == "" {
+		result.ExitPolicy.FailedPackages = true
+	}
+
 	if inputFile != "" {
 		result.InputStream, err = os.Open(inputFile)
 		if err != nil {
 			return Args{}, fmt.Errorf("failed to open input file %s. %s", inputFile, err)
 		}
 	} else {
-		result.NonZeroExitOnFailure = true
 		result.InputStream = os.Stdin
 	}
 
@@ -61,7 +209,51 @@ func ParseArgs(cmdArgs []string, fs *flag.FlagSet) (result Args, err error) {
 		result.OutputStream.Close()
 		return Args{}, err
 	}
+	if runURL != "" {
+		result.EnvArgs["RunURL"] = runURL
+	}
+
+	return result, nil
+}
+
+// ParsePlanArgs parses the arguments of the plan command. The first argument is the command name.
+func ParsePlanArgs(cmdArgs []string, fs *flag.FlagSet) (result PlanArgs, err error) {
+	fs.Usage = func() {
+		fmt.Println("go-testreport plan [<options>] <json test result files>")
+		fs.PrintDefaults()
+	}
+
+	var outputFile string
+	fs.IntVar(&result.Shards, "shards", 2, "Number of shards")
+	fs.StringVar(&result.PackagesFile, "packages", "", "File with the import paths of all packages which shall be planned, one per line such as the output of go list. "+
+		"Use - to read from stdin. If not set, all packages of the json test result files are planned")
+	fs.StringVar(&outputFile, "output", "", "Output JSON file. If not set, stdout will be used")
+
+	if err := fs.Parse(cmdArgs[1:]); err != nil {
+		return PlanArgs{}, err
+	}
+	result.HistoryFiles = fs.Args()
+	if len(result.HistoryFiles) == 0 {
+		return PlanArgs{}, fmt.Errorf("at least one json test result file is required")
+	}
+	// Parsing stops at the first file, so later flags would be taken as files
+	for _, file := range result.HistoryFiles {
+		if strings.HasPrefix(file, "-") {
+			return PlanArgs{}, fmt.Errorf("invalid json test result file %s. Flags must come before the files", file)
+		}
+	}
+	if result.Shards < 1 {
+		return PlanArgs{}, fmt.Errorf("shards must be at least 1")
+	}
 
+	if outputFile != "" {
+		result.OutputStream, err = os.Create(outputFile)
+		if err != nil {
+			return PlanArgs{}, fmt.Errorf("failed to open output file %s. %s", outputFile, err)
+		}
+	} else {
+		result.OutputStream = os.Stdout
+	}
 	return result, nil
 }
 
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/becheran/go-testreport/src/args"
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,7 +18,7 @@ func TestParseArgs_NoFile_UseStdOutAndIn(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, os.Stdout, res.OutputStream)
 	assert.Equal(t, os.Stdin, res.InputStream)
-	assert.True(t, res.NonZeroExitOnFailure)
+	assert.Equal(t, report.ExitPolicy{FailedPackages: true}, res.ExitPolicy)
 }
 
 func TestParseArgs_Files_UseFiles(t *testing.T) {
@@ -38,7 +40,7 @@ func TestParseArgs_Files_UseFiles(t *testing.T) {
 	require.Nil(t, err)
 	res.InputStream.Close()
 	assert.Equal(t, "test", string(readBytes))
-	assert.False(t, res.NonZeroExitOnFailure)
+	assert.Equal(t, report.ExitPolicy{}, res.ExitPolicy)
 }
 
 func TestParseArgs_CommaSeparatedList_ExpectedOutput(t *testing.T) {
@@ -78,3 +80,164 @@ func TestParseArgs_CommaSeparatedList_ExpectedOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseArgs_FailOn_IndependentOfInput(t *testing.T) {
+	file, err := os.Create(t.TempDir() + "/file")
+	require.Nil(t, err)
+	defer file.Close()
+
+	res, err := args.ParseArgs([]string{"exe", "-fail-on", "never"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, report.ExitPolicy{}, res.ExitPolicy)
+
+	res, err = args.ParseArgs([]string{"exe", "-fail-on", "failed-tests", "-input", file.Name()}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	res.InputStream.Close()
+	assert.Equal(t, report.ExitPolicy{FailedTests: true}, res.ExitPolicy)
+
+	_, err = args.ParseArgs([]string{"exe", "-fail-on", "foo"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_Filter(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-include", "package:api/*", "-include", "test-regex:^TestA", "-exclude", "test:*/skip", "-status", "fail"},
+		flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Len(t, res.Filter.IncludePackages, 1)
+	assert.Len(t, res.Filter.IncludeTests, 1)
+	assert.Len(t, res.Filter.ExcludeTests, 1)
+	assert.Equal(t, []report.FinalTestStatus{report.FTSFail}, res.Filter.Statuses)
+
+	_, err = args.ParseArgs([]string{"exe", "-include", "api/*"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+	_, err = args.ParseArgs([]string{"exe", "-status", "ok"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_Rerun(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-rerun", "2"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, 2, res.Rerun)
+
+	_, err = args.ParseArgs([]string{"exe", "-rerun", "-1"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParsePlanArgs(t *testing.T) {
+	res, err := args.ParsePlanArgs([]string{"plan", "-shards", "4", "-packages", "-", "a.json", "b.json"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, 4, res.Shards)
+	assert.Equal(t, "-", res.PackagesFile)
+	assert.Equal(t, []string{"a.json", "b.json"}, res.HistoryFiles)
+	assert.Equal(t, os.Stdout, res.OutputStream)
+
+	_, err = args.ParsePlanArgs([]string{"plan"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+	_, err = args.ParsePlanArgs([]string{"plan", "-shards", "0", "a.json"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+	_, err = args.ParsePlanArgs([]string{"plan", "a.json", "-shards", "4"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_Format(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-format", "chrome-trace"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "chrome-trace", res.Format)
+
+	_, err = args.ParseArgs([]string{"exe", "-format", "foo"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_OTLP(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-otlp-endpoint", "http://localhost:4318/v1/traces", "-otlp-header", "Authorization=Bearer a=b"},
+		flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "http://localhost:4318/v1/traces", res.OTLPEndpoint)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer a=b"}, res.OTLPHeaders)
+
+	_, err = args.ParseArgs([]string{"exe", "-otlp-header", "foo"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_MetricsMaxTests(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-format", "openmetrics", "-metrics-max-tests", "100"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, 100, res.FormatOptions.MaxTests)
+
+	_, err = args.ParseArgs([]string{"exe", "-metrics-max-tests", "-1"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_TeamCity(t *testing.T) {
+	output := t.TempDir() + "/report.md"
+	res, err := args.ParseArgs([]string{"exe", "-teamcity", "-output", output}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	defer res.OutputStream.Close()
+	assert.True(t, res.TeamCity)
+
+	_, err = args.ParseArgs([]string{"exe", "-teamcity"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+
+	_, err = args.ParseArgs([]string{"exe", "-teamcity", "-format", "teamcity", "-output", output}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+
+	_, err = args.ParseArgs([]string{"exe", "-teamcity", "-format", "ctrf"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_GitLab(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-gitlab", "reports"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "reports", res.GitLabDir)
+}
+
+func TestParseArgs_Webhook(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-webhook", "https://hooks.slack.com/services/T/B/X", "-run-url", "https://ci.example.com/run/1", "-vars", "Title:Nightly"},
+		flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "https://hooks.slack.com/services/T/B/X", res.Webhook)
+	assert.Equal(t, "slack", res.WebhookFormat)
+	assert.Equal(t, map[string]string{"Title": "Nightly", "RunURL": "https://ci.example.com/run/1"}, res.EnvArgs)
+
+	res, err = args.ParseArgs([]string{"exe", "-webhook-format", "teams"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "teams", res.WebhookFormat)
+
+	_, err = args.ParseArgs([]string{"exe", "-webhook-format", "tap"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_Email(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-email", "email.json", "-notify-state", "state.json"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "email.json", res.EmailFile)
+	assert.Equal(t, "state.json", res.StateFile)
+}
+
+func TestParseArgs_Badges(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-badges", "badges", "-badge-label", "unit tests", "-coverage-thresholds", "60,85", "-coverprofile", "cover.out"},
+		flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, "badges", res.BadgesDir)
+	assert.Equal(t, "cover.out", res.CoverProfile)
+	assert.Equal(t, format.BadgeOptions{Label: "unit tests", CoverageLabel: "coverage",
+		Thresholds: format.Thresholds{Red: 90, Yellow: 100}, CoverageThresholds: format.Thresholds{Red: 60, Yellow: 85}}, res.BadgeOptions)
+
+	_, err = args.ParseArgs([]string{"exe", "-badge-thresholds", "100,90"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+	_, err = args.ParseArgs([]string{"exe", "-coverage-thresholds", "x"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
+
+func TestParseArgs_Columns(t *testing.T) {
+	res, err := args.ParseArgs([]string{"exe", "-format", "csv", "-columns", "package,test,attempt"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"package", "test", "attempt"}, res.FormatOptions.Columns)
+
+	res, err = args.ParseArgs([]string{"exe", "-format", "ndjson"}, flag.NewFlagSet("test", flag.PanicOnError))
+	require.Nil(t, err)
+	assert.Equal(t, format.ColumnNames(), res.FormatOptions.Columns)
+
+	_, err = args.ParseArgs([]string{"exe", "-columns", "package,owner"}, flag.NewFlagSet("test", flag.PanicOnError))
+	assert.NotNil(t, err)
+}
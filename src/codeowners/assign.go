@@ -0,0 +1,68 @@
+package codeowners
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/becheran/go-testreport/src/gomod"
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// packageFile is the file name which is used to match the directory of a package.
+// The owners of a package are the owners of its test files.
+const packageFile = "_test.go"
+
+// Assign sets the owners of all packages and failed tests of the result. The import paths are
+// mapped to directories relative to the repository root with the modules of the result. The owners of a failed
+// test are taken from the first source location in its output and fall back to the package owners.
+func (c CodeOwners) Assign(result *report.Result, root string) {
+	for pIdx := range result.PackageResult {
+		pack := &result.PackageResult[pIdx]
+		dir, ok := gomod.PackageDir(result.Modules, string(pack.Name))
+		if !ok {
+			pack.Owners = nil
+			continue
+		}
+		relDir, ok := relative(root, dir)
+		if !ok {
+			pack.Owners = nil
+			continue
+		}
+		pack.Owners = c.Owners(filepath.Join(relDir, packageFile))
+		for tIdx := range pack.Tests {
+			test := &pack.Tests[tIdx]
+			test.Owners = nil
+			if test.TestResult != report.FTSFail {
+				continue
+			}
+			test.Owners = pack.Owners
+			for _, location := range test.Locations() {
+				file := location.File
+				if !filepath.IsAbs(file) {
+					file = filepath.Join(dir, filepath.Base(file))
+				}
+				if relFile, ok := relative(root, file); ok {
+					test.Owners = c.Owners(relFile)
+					break
+				}
+			}
+		}
+	}
+}
+
+// relative returns the path relative to the root if the path is inside of the root.
+func relative(root, path string) (string, bool) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
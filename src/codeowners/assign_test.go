@@ -0,0 +1,52 @@
+package codeowners_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/codeowners"
+	"github.com/becheran/go-testreport/src/gomod"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssign(t *testing.T) {
+	owners, err := codeowners.Parse(strings.NewReader(`* @all
+/api/ @api
+/api/handler_test.go @handler
+/tools/ @tools
+`))
+	require.Nil(t, err)
+	root := t.TempDir()
+	modules := []gomod.Module{
+		{Path: "example.com/repo", Dir: root},
+		{Path: "example.com/tools", Dir: filepath.Join(root, "tools")},
+	}
+	result := report.Result{Modules: modules, PackageResult: []report.PackageResult{
+		{Name: "example.com/repo/api", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestHandler", TestResult: report.FTSFail, Output: []report.OutputLine{{Text: "    handler_test.go:10: failed\n"}}},
+			{Name: "TestAbs", TestResult: report.FTSFail, Assertions: []report.Assertion{{Trace: filepath.Join(root, "api", "handler_test.go") + ":3"}}},
+			{Name: "TestOutside", TestResult: report.FTSFail, Assertions: []report.Assertion{{Trace: filepath.Join(filepath.Dir(root), "x_test.go") + ":3"}}},
+			{Name: "TestNoLocation", TestResult: report.FTSFail},
+			{Name: "TestPass", TestResult: report.FTSPass, Output: []report.OutputLine{{Text: "    handler_test.go:10: log\n"}}},
+		}},
+		{Name: "example.com/tools/gen", PackageResult: report.FTSPass},
+		{Name: "example.com/repo", PackageResult: report.FTSPass},
+		{Name: "other.com/x", PackageResult: report.FTSPass, Owners: []string{"@stale"}},
+	}}
+
+	owners.Assign(&result, root)
+
+	api := result.PackageResult[0]
+	assert.Equal(t, []string{"@api"}, api.Owners)
+	assert.Equal(t, []string{"@handler"}, api.Tests[0].Owners)
+	assert.Equal(t, []string{"@handler"}, api.Tests[1].Owners)
+	assert.Equal(t, []string{"@api"}, api.Tests[2].Owners)
+	assert.Equal(t, []string{"@api"}, api.Tests[3].Owners)
+	assert.Nil(t, api.Tests[4].Owners)
+	assert.Equal(t, []string{"@tools"}, result.PackageResult[1].Owners)
+	assert.Equal(t, []string{"@all"}, result.PackageResult[2].Owners)
+	assert.Nil(t, result.PackageResult[3].Owners)
+}
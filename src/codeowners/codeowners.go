@@ -0,0 +1,151 @@
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rule is a single line of a CODEOWNERS file.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	Section string // GitLab section name. Empty for GitHub files.
+	regex   *regexp.Regexp
+}
+
+// CodeOwners maps file paths to owners. Supports the GitHub and GitLab syntax.
+type CodeOwners struct {
+	Rules    []Rule
+	sections []string
+}
+
+var sectionRegex = regexp.MustCompile(`^\^?\[([^\]]+)\](\[\d+\])?\s*(.*)$`)
+
+// DefaultLocations are the paths relative to the repository root where GitHub and GitLab look for the CODEOWNERS file.
+var DefaultLocations = []string{"CODEOWNERS", filepath.Join(".github", "CODEOWNERS"), filepath.Join(".gitlab", "CODEOWNERS"), filepath.Join("docs", "CODEOWNERS")}
+
+// Load reads the CODEOWNERS file.
+func Load(pathToFile string) (CodeOwners, error) {
+	file, err := os.Open(pathToFile)
+	if err != nil {
+		return CodeOwners{}, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Root returns the repository root for the path of a CODEOWNERS file in one of the DefaultLocations.
+func Root(pathToFile string) string {
+	dir := filepath.Dir(pathToFile)
+	switch filepath.Base(dir) {
+	case ".github", ".gitlab", "docs":
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+func Parse(in io.Reader) (owners CodeOwners, err error) {
+	section := ""
+	var defaultOwners []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if match := sectionRegex.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			defaultOwners = strings.Fields(match[3])
+			owners.sections = append(owners.sections, section)
+			continue
+		}
+		fields := strings.Fields(line)
+		rule := Rule{Pattern: strings.ReplaceAll(fields[0], `\#`, "#"), Owners: fields[1:], Section: section}
+		if len(rule.Owners) == 0 && section != "" {
+			rule.Owners = defaultOwners
+		}
+		rule.regex = compile(rule.Pattern)
+		owners.Rules = append(owners.Rules, rule)
+	}
+	if err = scanner.Err(); err != nil {
+		return CodeOwners{}, err
+	}
+	return owners, nil
+}
+
+// compile converts a gitignore style pattern into a regular expression which
+// matches the path itself and all paths below it. A trailing "/*" only matches
+// the direct children of a directory.
+func compile(pattern string) *regexp.Regexp {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	directory := strings.HasSuffix(pattern, "/")
+	directChildren := strings.HasSuffix(pattern, "/*")
+	pattern = strings.Trim(pattern, "/")
+
+	expr := strings.Builder{}
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("(^|/)")
+	}
+	for idx := 0; idx < len(pattern); idx++ {
+		switch {
+		case strings.HasPrefix(pattern[idx:], "**/"):
+			expr.WriteString("(.*/)?")
+			idx += 2
+		case strings.HasPrefix(pattern[idx:], "**"):
+			expr.WriteString(".*")
+			idx++
+		case pattern[idx] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[idx] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[idx : idx+1]))
+		}
+	}
+	switch {
+	case directory:
+		expr.WriteString("/")
+	case directChildren:
+		expr.WriteString("$")
+	default:
+		expr.WriteString("(/|$)")
+	}
+	return regexp.MustCompile(expr.String())
+}
+
+// Owners returns the owners of the file path relative to the repository root.
+// The last matching rule wins. For GitLab files with sections, the owners of
+// the last matching rule of each section are combined.
+func (c CodeOwners) Owners(path string) (owners []string) {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	lastMatch := make(map[string]*Rule)
+	for idx := range c.Rules {
+		if c.Rules[idx].regex.MatchString(path) {
+			lastMatch[c.Rules[idx].Section] = &c.Rules[idx]
+		}
+	}
+	seen := make(map[string]bool)
+	for _, section := range append([]string{""}, c.sections...) {
+		rule, ok := lastMatch[section]
+		if !ok {
+			continue
+		}
+		delete(lastMatch, section)
+		for _, owner := range rule.Owners {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
@@ -0,0 +1,77 @@
+package codeowners_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/codeowners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwners_GitHub(t *testing.T) {
+	owners, err := codeowners.Parse(strings.NewReader(`# Comment
+*       @global-owner
+*.js    @js-owner # inline comment
+/build/logs/ @doctocat
+docs/*  docs@example.com
+apps/   @octocat
+/src/** @src-owner
+**/internal @internal-owner
+/scripts/ @scripts-owner
+/scripts/generated
+\#file  @hash-owner
+`))
+	require.Nil(t, err)
+
+	var suite = []struct {
+		path   string
+		owners []string
+	}{
+		{"main.go", []string{"@global-owner"}},
+		{"web/app.js", []string{"@js-owner"}},
+		{"build/logs/x/_test.go", []string{"@doctocat"}},
+		{"pkg/build/logs/_test.go", []string{"@global-owner"}},
+		{"docs/_test.go", []string{"docs@example.com"}},
+		{"docs/deep/_test.go", []string{"@global-owner"}},
+		{"pkg/apps/x/_test.go", []string{"@octocat"}},
+		{"src/a/b/_test.go", []string{"@src-owner"}},
+		{"src/a/internal/_test.go", []string{"@internal-owner"}},
+		{"internal/_test.go", []string{"@internal-owner"}},
+		{"scripts/_test.go", []string{"@scripts-owner"}},
+		{"scripts/generated/_test.go", nil},
+		{"#file", []string{"@hash-owner"}},
+		{filepath.Join(".", "web", "app.js"), []string{"@js-owner"}},
+	}
+	for _, s := range suite {
+		t.Run(s.path, func(t *testing.T) {
+			assert.Equal(t, s.owners, owners.Owners(s.path))
+		})
+	}
+}
+
+func TestOwners_GitLabSections(t *testing.T) {
+	owners, err := codeowners.Parse(strings.NewReader(`* @default
+
+[Backend] @backend-team
+*.go
+/api/ @api-owner
+
+^[Docs][2] @docs-team
+*.md
+`))
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"@default", "@backend-team"}, owners.Owners("pkg/_test.go"))
+	assert.Equal(t, []string{"@default", "@api-owner"}, owners.Owners("api/_test.go"))
+	assert.Equal(t, []string{"@default", "@api-owner", "@docs-team"}, owners.Owners("api/README.md"))
+	assert.Equal(t, "Docs", owners.Rules[3].Section)
+}
+
+func TestRoot(t *testing.T) {
+	assert.Equal(t, "repo", codeowners.Root(filepath.Join("repo", "CODEOWNERS")))
+	for _, location := range codeowners.DefaultLocations {
+		assert.Equal(t, "repo", codeowners.Root(filepath.Join("repo", location)))
+	}
+}
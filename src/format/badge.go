@@ -0,0 +1,226 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Files which are written by WriteBadges. The coverage badges are only written if the coverage was measured.
+const (
+	TestsBadgeFile            = "tests.svg"
+	TestsEndpointFile         = "tests.json"
+	CoverageBadgeFile         = "coverage.svg"
+	CoverageEndpointFile      = "coverage.json"
+	DefaultBadgeLabel         = "tests"
+	DefaultCoverageLabel      = "coverage"
+	DefaultBadgeThresholds    = "90,100"
+	DefaultCoverageThresholds = "50,80"
+)
+
+// Thresholds are the percentages below which a badge is red or yellow. It is green otherwise.
+type Thresholds struct {
+	Red    float64
+	Yellow float64
+}
+
+// ParseThresholds parses thresholds in the form <red>,<yellow> such as "50,80".
+func ParseThresholds(s string) (thresholds Thresholds, err error) {
+	red, yellow, ok := strings.Cut(s, ",")
+	if !ok {
+		return Thresholds{}, fmt.Errorf("thresholds must have the form <red>,<yellow>, got %s", s)
+	}
+	if thresholds.Red, err = strconv.ParseFloat(strings.TrimSpace(red), 64); err != nil {
+		return Thresholds{}, fmt.Errorf("invalid red threshold %s", red)
+	}
+	if thresholds.Yellow, err = strconv.ParseFloat(strings.TrimSpace(yellow), 64); err != nil {
+		return Thresholds{}, fmt.Errorf("invalid yellow threshold %s", yellow)
+	}
+	if thresholds.Red < 0 || thresholds.Yellow > 100 || thresholds.Red > thresholds.Yellow {
+		return Thresholds{}, fmt.Errorf("thresholds must be percentages with red not above yellow, got %s", s)
+	}
+	return thresholds, nil
+}
+
+// color returns the shields.io color of the percentage.
+func (t Thresholds) color(percent float64) string {
+	switch {
+	case percent < t.Red:
+		return "red"
+	case percent < t.Yellow:
+		return "yellow"
+	default:
+		return "brightgreen"
+	}
+}
+
+// BadgeOptions configure the labels and colors of the badges.
+type BadgeOptions struct {
+	Label              string
+	CoverageLabel      string
+	Thresholds         Thresholds // of the pass rate
+	CoverageThresholds Thresholds
+}
+
+// Badge is a badge with a label on the left and a message on a colored background on the right.
+// It is encoded as shields.io endpoint https://shields.io/badges/endpoint-badge
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// TestsBadge returns a badge with the number of passed, flaky and failed tests. The color depends on the pass rate
+// of the tests which were not skipped. Flaky tests count as passed and quarantined tests are not counted. The badge
+// is red if a package failed without failed tests, such as on build errors.
+func TestsBadge(result report.Result, opts BadgeOptions) Badge {
+	var passed, flaky, failed uint
+	packageFailed := false
+	for _, pack := range result.PackageResult {
+		failedTests := 0
+		for _, test := range pack.Tests {
+			switch {
+			case test.Flaky():
+				flaky++
+			case test.PersistentFailure() || test.TestResult == report.FTSIncomplete:
+				failed++
+				failedTests++
+			case test.TestResult == report.FTSPass:
+				passed++
+			}
+		}
+		packageFailed = packageFailed || (pack.Failed() && failedTests == 0)
+	}
+
+	parts := []string{fmt.Sprintf("%d passed", passed)}
+	if flaky > 0 {
+		parts = append(parts, fmt.Sprintf("%d flaky", flaky))
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", failed))
+	}
+	rate := 100.0
+	if executed := passed + flaky + failed; executed > 0 {
+		rate = float64(passed+flaky) * 100 / float64(executed)
+	}
+	color := opts.Thresholds.color(rate)
+	if packageFailed {
+		parts = append(parts, "build failed")
+		color = "red"
+	}
+	return Badge{SchemaVersion: 1, Label: opts.Label, Message: strings.Join(parts, ", "), Color: color}
+}
+
+// CoverageBadge returns a badge with the coverage or false if the coverage was not measured.
+func CoverageBadge(result report.Result, opts BadgeOptions) (Badge, bool) {
+	if result.Coverage == nil {
+		return Badge{}, false
+	}
+	coverage := *result.Coverage
+	return Badge{SchemaVersion: 1, Label: opts.CoverageLabel, Message: fmt.Sprintf("%.1f%%", coverage),
+		Color: opts.CoverageThresholds.color(coverage)}, true
+}
+
+// badgeColors are the colors of the flat shields.io style.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"yellow":      "#dfb317",
+	"red":         "#e05d44",
+}
+
+// textWidth estimates the width of the text in 11px Verdana, which is used by shields.io.
+func textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("ijlI.,:;'|!", r):
+			width += 3.5
+		case strings.ContainsRune("frt() ", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW%", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(width + 0.5)
+}
+
+// SVG writes the badge as standalone SVG in the flat shields.io style.
+func (b Badge) SVG(out io.Writer) error {
+	color, ok := badgeColors[b.Color]
+	if !ok {
+		color = b.Color
+	}
+	labelWidth, messageWidth := textWidth(b.Label)+10, textWidth(b.Message)+10
+	width := labelWidth + messageWidth
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+	title := label + ": " + message
+	_, err := fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s">
+<title>%[2]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[3]d" height="20" fill="#555"/><rect x="%[3]d" width="%[4]d" height="20" fill="%[5]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[6]d" y="15" fill="#010101" fill-opacity=".3">%[7]s</text><text x="%[6]d" y="14">%[7]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[9]s</text><text x="%[8]d" y="14">%[9]s</text>
+</g>
+</svg>
+`, width, title, labelWidth, messageWidth, html.EscapeString(color), labelWidth/2, label, labelWidth+messageWidth/2, message)
+	return err
+}
+
+// JSON writes the badge as shields.io endpoint.
+func (b Badge) JSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+func writeFile(dir, name string, write func(out io.Writer) error) error {
+	out, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	err = write(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s. %s", name, err)
+	}
+	return nil
+}
+
+// WriteBadges writes the tests badge as SVG and shields.io endpoint JSON to the directory. The coverage
+// badges are written too if the coverage was measured.
+func WriteBadges(result report.Result, dir string, opts BadgeOptions) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	type file struct {
+		name  string
+		write func(out io.Writer) error
+	}
+	tests := TestsBadge(result, opts)
+	files := []file{{TestsBadgeFile, tests.SVG}, {TestsEndpointFile, tests.JSON}}
+	if coverage, ok := CoverageBadge(result, opts); ok {
+		files = append(files, file{CoverageBadgeFile, coverage.SVG}, file{CoverageEndpointFile, coverage.JSON})
+	}
+	for _, f := range files {
+		if err := writeFile(dir, f.name, f.write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var badgeOptions = format.BadgeOptions{Label: "tests", CoverageLabel: "coverage",
+	Thresholds: format.Thresholds{Red: 90, Yellow: 100}, CoverageThresholds: format.Thresholds{Red: 50, Yellow: 80}}
+
+func tests(statuses ...report.FinalTestStatus) []report.TestResult {
+	var tests []report.TestResult
+	for _, status := range statuses {
+		tests = append(tests, report.TestResult{Name: "Test" + status.String(), TestResult: status})
+	}
+	return tests
+}
+
+func TestParseThresholds(t *testing.T) {
+	var suite = []struct {
+		in         string
+		thresholds format.Thresholds
+		isErr      bool
+	}{
+		{"50,80", format.Thresholds{Red: 50, Yellow: 80}, false},
+		{"90.5, 100", format.Thresholds{Red: 90.5, Yellow: 100}, false},
+		{"80,80", format.Thresholds{Red: 80, Yellow: 80}, false},
+		{"80", format.Thresholds{}, true},
+		{"a,80", format.Thresholds{}, true},
+		{"50,b", format.Thresholds{}, true},
+		{"80,50", format.Thresholds{}, true},
+		{"-1,50", format.Thresholds{}, true},
+		{"50,101", format.Thresholds{}, true},
+	}
+	for _, s := range suite {
+		t.Run(s.in, func(t *testing.T) {
+			thresholds, err := format.ParseThresholds(s.in)
+			if s.isErr {
+				assert.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+				assert.Equal(t, s.thresholds, thresholds)
+			}
+		})
+	}
+}
+
+func TestTestsBadge(t *testing.T) {
+	flaky := report.TestResult{Name: "TestFlaky", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}}
+	quarantined := report.TestResult{Name: "TestQuarantined", TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}}
+	many := make([]report.FinalTestStatus, 0, 20)
+	for idx := 0; idx < 19; idx++ {
+		many = append(many, report.FTSPass)
+	}
+	var suite = []struct {
+		name    string
+		pack    report.PackageResult
+		message string
+		color   string
+	}{
+		{"passed", report.PackageResult{PackageResult: report.FTSPass, Tests: tests(report.FTSPass, report.FTSPass, report.FTPSSkip)}, "2 passed", "brightgreen"},
+		{"no tests", report.PackageResult{PackageResult: report.FTSPass}, "0 passed", "brightgreen"},
+		{"failed", report.PackageResult{PackageResult: report.FTSFail, Tests: tests(report.FTSPass, report.FTSFail)}, "1 passed, 1 failed", "red"},
+		{"below yellow", report.PackageResult{PackageResult: report.FTSFail, Tests: tests(append(many, report.FTSFail)...)}, "19 passed, 1 failed", "yellow"},
+		{"incomplete", report.PackageResult{PackageResult: report.FTSFail, Tests: tests(report.FTSPass, report.FTSIncomplete)}, "1 passed, 1 failed", "red"},
+		{"flaky", report.PackageResult{PackageResult: report.FTSFail, Tests: append(tests(report.FTSPass), flaky)}, "1 passed, 1 flaky", "brightgreen"},
+		{"quarantined", report.PackageResult{PackageResult: report.FTSFail, Tests: append(tests(report.FTSPass), quarantined)}, "1 passed", "brightgreen"},
+		{"build failed", report.PackageResult{PackageResult: report.FTSFail}, "0 passed, build failed", "red"},
+	}
+	for _, s := range suite {
+		t.Run(s.name, func(t *testing.T) {
+			badge := format.TestsBadge(report.Result{PackageResult: []report.PackageResult{s.pack}}, badgeOptions)
+			assert.Equal(t, format.Badge{SchemaVersion: 1, Label: "tests", Message: s.message, Color: s.color}, badge)
+		})
+	}
+}
+
+func TestCoverageBadge(t *testing.T) {
+	_, ok := format.CoverageBadge(report.Result{}, badgeOptions)
+	assert.False(t, ok)
+
+	var suite = []struct {
+		coverage float64
+		message  string
+		color    string
+	}{
+		{12.34, "12.3%", "red"},
+		{50, "50.0%", "yellow"},
+		{80, "80.0%", "brightgreen"},
+	}
+	for _, s := range suite {
+		t.Run(s.message, func(t *testing.T) {
+			coverage := s.coverage
+			badge, ok := format.CoverageBadge(report.Result{Coverage: &coverage}, badgeOptions)
+			require.True(t, ok)
+			assert.Equal(t, format.Badge{SchemaVersion: 1, Label: "coverage", Message: s.message, Color: s.color}, badge)
+		})
+	}
+}
+
+func TestBadgeSVG(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.Badge{Label: "<tests>", Message: "1 passed & 2 failed", Color: "red"}.SVG(buff))
+
+	var svg struct {
+		Width string   `xml:"width,attr"`
+		Title string   `xml:"title"`
+		Texts []string `xml:"g>text"`
+		Rects []struct {
+			Fill string `xml:"fill,attr"`
+		} `xml:"g>rect"`
+	}
+	require.Nil(t, xml.Unmarshal(buff.Bytes(), &svg))
+	assert.Equal(t, "<tests>: 1 passed & 2 failed", svg.Title)
+	assert.Equal(t, []string{"<tests>", "<tests>", "1 passed & 2 failed", "1 passed & 2 failed"}, svg.Texts)
+	require.Len(t, svg.Rects, 3)
+	assert.Equal(t, "#e05d44", svg.Rects[1].Fill)
+	assert.NotEqual(t, "", svg.Width)
+}
+
+func TestWriteBadges(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "badges")
+	result := report.Result{PackageResult: []report.PackageResult{{PackageResult: report.FTSPass, Tests: tests(report.FTSPass)}}}
+
+	require.Nil(t, format.WriteBadges(result, dir, badgeOptions))
+
+	content, err := os.ReadFile(filepath.Join(dir, format.TestsEndpointFile))
+	require.Nil(t, err)
+	var badge format.Badge
+	require.Nil(t, json.Unmarshal(content, &badge))
+	assert.Equal(t, format.Badge{SchemaVersion: 1, Label: "tests", Message: "1 passed", Color: "brightgreen"}, badge)
+	assert.FileExists(t, filepath.Join(dir, format.TestsBadgeFile))
+	assert.NoFileExists(t, filepath.Join(dir, format.CoverageBadgeFile))
+	assert.NoFileExists(t, filepath.Join(dir, format.CoverageEndpointFile))
+
+	coverage := 75.0
+	result.Coverage = &coverage
+	require.Nil(t, format.WriteBadges(result, dir, badgeOptions))
+
+	content, err = os.ReadFile(filepath.Join(dir, format.CoverageEndpointFile))
+	require.Nil(t, err)
+	require.Nil(t, json.Unmarshal(content, &badge))
+	assert.Equal(t, format.Badge{SchemaVersion: 1, Label: "coverage", Message: "75.0%", Color: "yellow"}, badge)
+	assert.FileExists(t, filepath.Join(dir, format.CoverageBadgeFile))
+}
@@ -0,0 +1,87 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// maxChatFailures is the number of failures which are listed in chat messages.
+const maxChatFailures = 10
+
+// maxChatMessage is the maximum length of the failure message of a single test in chat messages.
+const maxChatMessage = 500
+
+// truncate shortens the text to at most max runes. Truncated text ends with an ellipsis.
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max-1]) + "…"
+}
+
+// chatTitle is the Title variable or the default title of the report.
+func chatTitle(result report.Result) string {
+	if title := result.Vars["Title"]; title != "" {
+		return title
+	}
+	return "Test Report"
+}
+
+// chatSummary returns the counts of the result in the style of the markdown report.
+func chatSummary(result report.Result) string {
+	summary := fmt.Sprintf("Total: %d ✔️ Passed: %d ⏩ Skipped: %d ❌ Failed: %d", result.Tests, result.Passed, result.Skipped, result.Failed)
+	if result.Flaky > 0 {
+		summary += fmt.Sprintf(" 🔁 Flaky: %d", result.Flaky)
+	}
+	if result.Incomplete > 0 {
+		summary += fmt.Sprintf(" ⏳ Incomplete: %d", result.Incomplete)
+	}
+	if result.Quarantined > 0 {
+		summary += fmt.Sprintf(" 🔒 Quarantined: %d", result.Quarantined)
+	}
+	return summary + fmt.Sprintf(" ⏱️ Duration: %s", result.Duration)
+}
+
+// chatFailures returns the first failures which are neither flaky nor quarantined and the number of
+// failures which are not returned.
+func chatFailures(result report.Result, max int) (res []failure, more int) {
+	for _, f := range failures(result) {
+		if f.severity != "error" {
+			continue
+		}
+		if len(res) < max {
+			res = append(res, f)
+		} else {
+			more++
+		}
+	}
+	return res, more
+}
+
+// chatFailure returns the package and the name of the failed test or package and the shortened message.
+func chatFailure(f failure) (name, message string) {
+	name = string(f.pack.Name)
+	if f.test != nil {
+		name = f.test.Name
+	}
+	message = f.message
+	if f.test != nil && len(f.test.Attempts) > 0 {
+		message += fmt.Sprintf(" (failed %d reruns)", len(f.test.Attempts))
+	}
+	return name, truncate(strings.TrimSpace(message), maxChatMessage)
+}
+
+// SendWebhook posts the result in the format to the incoming webhook of a chat, such as Slack or Microsoft Teams.
+func SendWebhook(client *http.Client, url string, write Writer, result report.Result) error {
+	body := bytes.NewBuffer(nil)
+	if err := write(result, body, Options{}); err != nil {
+		return err
+	}
+	return post(client, url, nil, body)
+}
@@ -0,0 +1,221 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatResult has the given number of failed tests with long messages, a flaky and a quarantined test.
+func chatResult(failed int) report.Result {
+	pack := report.PackageResult{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "TestFlaky", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}},
+		{Name: "TestQuarantined", TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+	}}
+	for idx := 0; idx < failed; idx++ {
+		pack.Tests = append(pack.Tests, report.TestResult{Name: fmt.Sprintf("TestFail%d", idx), TestResult: report.FTSFail,
+			Output: output("    a_test.go:10: <b> & ```" + strings.Repeat("x", 1000))})
+	}
+	return report.Result{Tests: uint(failed) + 2, Failed: uint(failed) + 2, Flaky: 1, Quarantined: 1, PackageResult: []report.PackageResult{pack},
+		Vars: map[string]string{"Title": "Nightly", "RunURL": "https://ci.example.com/run/1"}}
+}
+
+type slackMessage struct {
+	Text   string `json:"text"`
+	Blocks []struct {
+		Type string `json:"type"`
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+		Elements []map[string]any `json:"elements"`
+	} `json:"blocks"`
+}
+
+func TestSlack(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.Slack(chatResult(12), buff, format.Options{}))
+
+	var msg slackMessage
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &msg))
+	assert.Equal(t, "Nightly: 12 of 14 tests failed", msg.Text)
+	require.Len(t, msg.Blocks, 15)
+	assert.Equal(t, "header", msg.Blocks[0].Type)
+	assert.Equal(t, "Nightly", msg.Blocks[0].Text.Text)
+	assert.Contains(t, msg.Blocks[1].Text.Text, "🔁 Flaky: 1")
+	assert.Equal(t, "divider", msg.Blocks[2].Type)
+	for _, block := range msg.Blocks[3:13] {
+		assert.Equal(t, "section", block.Type)
+		assert.LessOrEqual(t, len([]rune(block.Text.Text)), 3000)
+		assert.Contains(t, block.Text.Text, "a_test.go:10: &lt;b&gt; &amp; '''")
+		assert.Equal(t, 2, strings.Count(block.Text.Text, "```"))
+	}
+	assert.Contains(t, msg.Blocks[3].Text.Text, "*TestFail0*")
+	assert.Equal(t, "context", msg.Blocks[13].Type)
+	assert.Equal(t, "and 2 more failures", msg.Blocks[13].Elements[0]["text"])
+	assert.Equal(t, "actions", msg.Blocks[14].Type)
+	assert.Equal(t, "https://ci.example.com/run/1", msg.Blocks[14].Elements[0]["url"])
+}
+
+func TestSlack_Escape(t *testing.T) {
+	pack := report.PackageResult{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "Test" + strings.Repeat("&", 200), TestResult: report.FTSFail, Output: output("    a_test.go:10: " + strings.Repeat("&", 1000))},
+	}}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.Slack(report.Result{Tests: 1, Failed: 1, PackageResult: []report.PackageResult{pack}, Vars: map[string]string{"Title": "<!channel>"}}, buff, format.Options{}))
+
+	var msg slackMessage
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &msg))
+	assert.Equal(t, "&lt;!channel&gt;: 1 of 1 tests failed", msg.Text)
+	text := msg.Blocks[3].Text.Text
+	assert.LessOrEqual(t, len([]rune(text)), 3000)
+	assert.True(t, strings.HasSuffix(text, "&amp;…```"), text)
+	assert.Equal(t, 2, strings.Count(text, "```"))
+}
+
+func TestSlack_Passed(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.Slack(report.Result{Tests: 1, Passed: 1}, buff, format.Options{}))
+
+	var msg slackMessage
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &msg))
+	require.Len(t, msg.Blocks, 2)
+	assert.Equal(t, "Test Report passed", msg.Text)
+	assert.Equal(t, "Test Report", msg.Blocks[0].Text.Text)
+}
+
+func TestSlack_Text(t *testing.T) {
+	buildFailed := report.Result{PackageResult: []report.PackageResult{{Name: "example.com/bad", PackageResult: report.FTSFail}}}
+	var suite = []struct {
+		name   string
+		result report.Result
+		text   string
+	}{
+		{"failed", chatResult(1), "Nightly: 1 of 3 tests failed"},
+		{"flaky and quarantined", chatResult(0), "Nightly passed"},
+		{"build failed", buildFailed, "Test Report failed"},
+	}
+	for _, s := range suite {
+		t.Run(s.name, func(t *testing.T) {
+			buff := bytes.NewBuffer(nil)
+
+			require.Nil(t, format.Slack(s.result, buff, format.Options{}))
+
+			var msg slackMessage
+			require.Nil(t, json.Unmarshal(buff.Bytes(), &msg))
+			assert.Equal(t, s.text, msg.Text)
+		})
+	}
+}
+
+type teamsMessage struct {
+	Type        string `json:"type"`
+	Attachments []struct {
+		ContentType string `json:"contentType"`
+		Content     struct {
+			Type string `json:"type"`
+			Body []struct {
+				Type  string `json:"type"`
+				Text  string `json:"text"`
+				Facts []struct {
+					Title string `json:"title"`
+					Value string `json:"value"`
+				} `json:"facts"`
+				Items []struct {
+					Text string `json:"text"`
+				} `json:"items"`
+			} `json:"body"`
+			Actions []struct {
+				URL string `json:"url"`
+			} `json:"actions"`
+		} `json:"content"`
+	} `json:"attachments"`
+}
+
+func TestTeams(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.Teams(chatResult(2), buff, format.Options{}))
+
+	var msg teamsMessage
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &msg))
+	assert.Equal(t, "message", msg.Type)
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", msg.Attachments[0].ContentType)
+	card := msg.Attachments[0].Content
+	assert.Equal(t, "AdaptiveCard", card.Type)
+	require.Len(t, card.Body, 4)
+	assert.Equal(t, "Nightly", card.Body[0].Text)
+	assert.Contains(t, fmt.Sprint(card.Body[1].Facts), "{Flaky 1}")
+	assert.Equal(t, "❌ TestFail0", card.Body[2].Items[0].Text)
+	assert.Equal(t, "example.com/a", card.Body[2].Items[1].Text)
+	assert.True(t, strings.HasSuffix(card.Body[2].Items[2].Text, "…"))
+	assert.Equal(t, "https://ci.example.com/run/1", card.Actions[0].URL)
+}
+
+func TestTeams_SizeLimit(t *testing.T) {
+	result := chatResult(10)
+	for idx := range result.PackageResult[0].Tests {
+		result.PackageResult[0].Tests[idx].Name += strings.Repeat("Long", 1000)
+	}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.Teams(result, buff, format.Options{}))
+
+	assert.LessOrEqual(t, buff.Len(), 28*1024)
+	var msg teamsMessage
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &msg))
+	body := msg.Attachments[0].Content.Body
+	assert.Regexp(t, `^and \d+ more failures$`, body[len(body)-1].Text)
+}
+
+func TestSendWebhook(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	require.Nil(t, format.SendWebhook(server.Client(), server.URL, format.Slack, chatResult(1)))
+
+	var msg slackMessage
+	require.Nil(t, json.Unmarshal(body, &msg))
+	assert.Equal(t, "Nightly: 1 of 3 tests failed", msg.Text)
+}
+
+func TestSendWebhook_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := format.SendWebhook(server.Client(), server.URL, format.Teams, chatResult(1))
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid_payload")
+}
+
+func TestSendWebhook_ErrorWithoutURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	err := format.SendWebhook(server.Client(), server.URL+"/services/T000/SECRETTOKEN", format.Slack, chatResult(1))
+
+	require.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "SECRETTOKEN")
+}
@@ -0,0 +1,155 @@
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// traceEvent is an event of the Trace Event Format which is used by chrome://tracing and Perfetto.
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	Ts    float64        `json:"ts"` // microseconds since the start of the run
+	Dur   *float64       `json:"dur,omitempty"`
+	Pid   int            `json:"pid"`
+	Tid   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+type trace struct {
+	TraceEvents     []traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+}
+
+// slice is a period of a test on a thread of the trace.
+type slice struct {
+	start, end time.Time
+	test       string
+}
+
+// fits is true if the slice is disjoint from all slices of the lane or nested in the slices of its
+// parent tests. Viewers nest slices of a thread which are contained in each other.
+func (s slice) fits(lane []slice) bool {
+	for _, other := range lane {
+		disjoint := !s.start.Before(other.end) || !other.start.Before(s.end)
+		nested := strings.HasPrefix(s.test, other.test+"/") && !s.start.Before(other.start) && !s.end.After(other.end)
+		if !disjoint && !nested {
+			return false
+		}
+	}
+	return true
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
+
+// ChromeTrace writes the result in the Trace Event Format. Every package is a process with the
+// package slice on the first thread. Tests and subtests are nested slices on the following threads.
+// Tests which run in parallel are placed on different threads. Paused periods of parallel tests
+// are nested slices and failures are instant events.
+func ChromeTrace(result report.Result, out io.Writer, _ Options) error {
+	start := result.Span().Start
+	ts := func(t time.Time) float64 {
+		return microseconds(t.Sub(start))
+	}
+	events := []traceEvent{}
+	complete := func(name, cat string, pid, tid int, s slice, args map[string]any) {
+		dur := microseconds(s.end.Sub(s.start))
+		events = append(events, traceEvent{Name: name, Cat: cat, Phase: "X", Ts: ts(s.start), Dur: &dur, Pid: pid, Tid: tid, Args: args})
+	}
+
+	for pIdx, pack := range result.PackageResult {
+		if pack.Started.IsZero() {
+			continue
+		}
+		pid := pIdx + 1
+		events = append(events,
+			traceEvent{Name: "process_name", Phase: "M", Pid: pid, Args: map[string]any{"name": string(pack.Name)}},
+			traceEvent{Name: "thread_name", Phase: "M", Pid: pid, Tid: 0, Args: map[string]any{"name": "package"}})
+		complete(string(pack.Name), "package", pid, 0, slice{start: pack.Started, end: pack.Ended}, map[string]any{"status": pack.PackageResult.String()})
+		if pack.PackageResult == report.FTSFail {
+			events = append(events, traceEvent{Name: "FAIL " + string(pack.Name), Cat: "failure", Phase: "i", Ts: ts(pack.Ended), Pid: pid, Tid: 0, Scope: "t"})
+		}
+
+		tests := make([]report.TestResult, 0, len(pack.Tests))
+		for _, test := range pack.Tests {
+			if !test.Started.IsZero() {
+				tests = append(tests, test)
+			}
+		}
+		// Parents before their subtests so that the subtests can be nested in the lane of the parent
+		sort.SliceStable(tests, func(i, j int) bool {
+			if !tests[i].Started.Equal(tests[j].Started) {
+				return tests[i].Started.Before(tests[j].Started)
+			}
+			return strings.Count(tests[i].Name, "/") < strings.Count(tests[j].Name, "/")
+		})
+
+		var lanes [][]slice
+		laneOfTest := make(map[string]int)
+		for _, test := range tests {
+			s := slice{start: test.Started, end: test.Ended, test: test.Name}
+			testSlices := []slice{s}
+			for _, pause := range test.Pauses {
+				end := pause.End
+				if end.IsZero() {
+					end = test.Ended
+				}
+				testSlices = append(testSlices, slice{start: pause.Start, end: end, test: test.Name})
+			}
+			lane := -1
+			candidates := make([]int, 0, len(lanes)+1)
+			if idx := strings.LastIndex(test.Name, "/"); idx >= 0 {
+				if parentLane, ok := laneOfTest[test.Name[:idx]]; ok {
+					candidates = append(candidates, parentLane)
+				}
+			}
+			for idx := range lanes {
+				candidates = append(candidates, idx)
+			}
+			for _, candidate := range candidates {
+				if s.fits(lanes[candidate]) {
+					lane = candidate
+					break
+				}
+			}
+			if lane < 0 {
+				lane = len(lanes)
+				lanes = append(lanes, nil)
+				events = append(events, traceEvent{Name: "thread_name", Phase: "M", Pid: pid, Tid: lane + 1, Args: map[string]any{"name": "tests"}})
+			}
+			lanes[lane] = append(lanes[lane], testSlices...)
+			laneOfTest[test.Name] = lane
+			tid := lane + 1
+
+			args := map[string]any{"status": test.Classification()}
+			if len(test.Attempts) > 0 {
+				attempts := make([]string, 0, len(test.Attempts))
+				for _, attempt := range test.Attempts {
+					attempts = append(attempts, attempt.TestResult.String())
+				}
+				args["attempts"] = attempts
+			}
+			complete(test.Name, "test", pid, tid, s, args)
+			for _, pause := range testSlices[1:] {
+				complete("paused", "pause", pid, tid, pause, nil)
+			}
+			if test.TestResult == report.FTSFail {
+				events = append(events, traceEvent{Name: "FAIL " + test.Name, Cat: "failure", Phase: "i", Ts: ts(test.Ended), Pid: pid, Tid: tid, Scope: "t"})
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(trace{TraceEvents: events, DisplayTimeUnit: "ms"})
+}
@@ -0,0 +1,75 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestA and TestB run in parallel. TestA has a subtest and was paused.
+const parallelJson = `{"Time":"2024-01-01T10:00:00Z","Action":"start","Package":"example.com/a"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:00Z","Action":"pause","Package":"example.com/a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"example.com/a","Test":"TestB"}
+{"Time":"2024-01-01T10:00:01Z","Action":"cont","Package":"example.com/a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:01Z","Action":"run","Package":"example.com/a","Test":"TestA/sub"}
+{"Time":"2024-01-01T10:00:02Z","Action":"fail","Package":"example.com/a","Test":"TestA/sub","Elapsed":1}
+{"Time":"2024-01-01T10:00:02Z","Action":"fail","Package":"example.com/a","Test":"TestA","Elapsed":1}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Package":"example.com/a","Test":"TestB","Elapsed":3}
+{"Time":"2024-01-01T10:00:03Z","Action":"fail","Package":"example.com/a","Elapsed":3}
+`
+
+type event struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat"`
+	Phase string         `json:"ph"`
+	Ts    float64        `json:"ts"`
+	Dur   *float64       `json:"dur"`
+	Pid   int            `json:"pid"`
+	Tid   int            `json:"tid"`
+	Args  map[string]any `json:"args"`
+}
+
+func TestChromeTrace(t *testing.T) {
+	result, err := report.ParseTestJson(strings.NewReader(parallelJson))
+	require.Nil(t, err)
+	result.PackageResult[0].Tests[0].Attempts = []report.Attempt{{TestResult: report.FTSPass}}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.ChromeTrace(result, buff, format.Options{}))
+
+	var trace struct {
+		TraceEvents []event `json:"traceEvents"`
+	}
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &trace))
+	slices := make(map[string]event)
+	var instants []string
+	for _, evt := range trace.TraceEvents {
+		switch evt.Phase {
+		case "X":
+			slices[evt.Cat+":"+evt.Name] = evt
+		case "i":
+			instants = append(instants, evt.Name)
+		}
+	}
+
+	require.Len(t, slices, 5)
+	pack := slices["package:example.com/a"]
+	assert.Equal(t, 0, pack.Tid)
+	assert.Equal(t, 3e6, *pack.Dur)
+	testA, sub, testB, paused := slices["test:TestA"], slices["test:TestA/sub"], slices["test:TestB"], slices["pause:paused"]
+	assert.Equal(t, testA.Tid, sub.Tid, "subtests are nested in the parent")
+	assert.Equal(t, testA.Tid, paused.Tid)
+	assert.NotEqual(t, testA.Tid, testB.Tid, "parallel tests are on different threads")
+	assert.Equal(t, 1e6, sub.Ts)
+	assert.Equal(t, 1e6, *paused.Dur)
+	assert.Equal(t, "flaky", testA.Args["status"])
+	assert.Equal(t, []any{"pass"}, testA.Args["attempts"])
+	assert.ElementsMatch(t, []string{"FAIL example.com/a", "FAIL TestA", "FAIL TestA/sub"}, instants)
+}
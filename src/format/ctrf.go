@@ -0,0 +1,172 @@
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Common Test Report Format
+// https://ctrf.io/docs/schema/overview
+type ctrfReport struct {
+	ReportFormat string      `json:"reportFormat"`
+	SpecVersion  string      `json:"specVersion"`
+	Results      ctrfResults `json:"results"`
+}
+
+type ctrfResults struct {
+	Tool    ctrfTool    `json:"tool"`
+	Summary ctrfSummary `json:"summary"`
+	Tests   []ctrfTest  `json:"tests"`
+}
+
+type ctrfTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type ctrfSummary struct {
+	Tests   int            `json:"tests"`
+	Passed  int            `json:"passed"`
+	Failed  int            `json:"failed"`
+	Pending int            `json:"pending"`
+	Skipped int            `json:"skipped"`
+	Other   int            `json:"other"`
+	Suites  int            `json:"suites"`
+	Start   int64          `json:"start"`
+	Stop    int64          `json:"stop"`
+	Extra   map[string]any `json:"extra,omitempty"`
+}
+
+type ctrfTest struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Duration  int64  `json:"duration"`
+	Start     int64  `json:"start,omitempty"`
+	Stop      int64  `json:"stop,omitempty"`
+	Suite     string `json:"suite"`
+	Message   string `json:"message,omitempty"`
+	Trace     string `json:"trace,omitempty"`
+	RawStatus string `json:"rawStatus"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Retries   int    `json:"retries,omitempty"`
+	Flaky     bool   `json:"flaky,omitempty"`
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// ctrfStatus maps the status of the last attempt of the test.
+func ctrfStatus(test report.TestResult) string {
+	switch test.LastStatus() {
+	case report.FTSPass:
+		return "passed"
+	case report.FTSFail:
+		return "failed"
+	case report.FTPSSkip:
+		return "skipped"
+	default:
+		return "other"
+	}
+}
+
+// failureMessage returns the message and trace of the failed test. Parsed assertions are preferred
+// over the raw test output.
+func failureMessage(test report.TestResult) (message, trace string) {
+	output := strings.Builder{}
+	for _, line := range test.Output {
+		output.WriteString(line.Text)
+	}
+	if len(test.Assertions) > 0 {
+		assertion := test.Assertions[0]
+		trace = assertion.Trace
+		if trace == "" {
+			trace = output.String()
+		}
+		return assertion.Message, trace
+	}
+	for _, line := range test.Output {
+		if text := strings.TrimSpace(line.Text); text != "" && !strings.HasPrefix(text, "=== ") {
+			return text, output.String()
+		}
+	}
+	return "", output.String()
+}
+
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
+
+// CTRF writes the result in the Common Test Report Format. Every test is reported with the status of its
+// last attempt. Reruns are counted as retries and tests which passed on a rerun are flaky.
+func CTRF(result report.Result, out io.Writer, _ Options) error {
+	span := result.Span()
+	ctrf := ctrfReport{ReportFormat: "CTRF", SpecVersion: "0.0.0", Results: ctrfResults{
+		Tool: ctrfTool{Name: "go-testreport", Version: toolVersion()},
+		Summary: ctrfSummary{
+			Suites: len(result.PackageResult),
+			Start:  unixMilli(span.Start),
+			Stop:   unixMilli(span.End),
+		},
+		Tests: []ctrfTest{},
+	}}
+	summary := &ctrf.Results.Summary
+	flaky := 0
+	for _, pack := range result.PackageResult {
+		for _, test := range pack.Tests {
+			entry := ctrfTest{
+				Name:      test.Name,
+				Status:    ctrfStatus(test),
+				Duration:  test.Duration.Milliseconds(),
+				Start:     unixMilli(test.Started),
+				Stop:      unixMilli(test.Ended),
+				Suite:     string(pack.Name),
+				RawStatus: test.Classification(),
+				Type:      "unit",
+				Retries:   len(test.Attempts),
+				Flaky:     test.Flaky(),
+			}
+			if test.TestResult == report.FTSFail || test.TestResult == report.FTSIncomplete {
+				entry.Message, entry.Trace = failureMessage(test)
+				if locations := test.Locations(); len(locations) > 0 {
+					if file, ok := pack.SourceFile(locations[0]); ok {
+						entry.FilePath, entry.Line = file, locations[0].Line
+					}
+				}
+			}
+			switch entry.Status {
+			case "passed":
+				summary.Passed++
+			case "failed":
+				summary.Failed++
+			case "skipped":
+				summary.Skipped++
+			default:
+				summary.Other++
+			}
+			if entry.Flaky {
+				flaky++
+			}
+			ctrf.Results.Tests = append(ctrf.Results.Tests, entry)
+		}
+	}
+	summary.Tests = len(ctrf.Results.Tests)
+	summary.Extra = map[string]any{"flaky": flaky, "quarantined": result.Quarantined}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ctrf)
+}
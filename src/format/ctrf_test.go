@@ -0,0 +1,87 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ctrfSchema is the schema of CTRF spec version 0.0.0, which is vendored from
+// https://github.com/ctrf-io/ctrf/blob/main/schema/ctrf.schema.json
+const ctrfSchema = "testdata/ctrf.schema.json"
+
+func TestCTRF(t *testing.T) {
+	result, err := report.ParseTestJson(strings.NewReader(parallelJson))
+	require.Nil(t, err)
+	pack := &result.PackageResult[0]
+	pack.Dir = "pkg/a"
+	for idx := range pack.Tests {
+		test := &pack.Tests[idx]
+		switch test.Name {
+		case "TestA":
+			test.Attempts = []report.Attempt{{TestResult: report.FTSPass}}
+		case "TestA/sub":
+			test.Assertions = []report.Assertion{{Trace: "a_test.go:12", Message: "Should be true"}}
+		}
+	}
+	result.UpdateTotals()
+	result.Sort(report.SortByName)
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.CTRF(result, buff, format.Options{}))
+
+	schema, err := jsonschema.Compile(ctrfSchema)
+	require.Nil(t, err)
+	var ctrf any
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &ctrf))
+	assert.Nil(t, schema.Validate(ctrf))
+	assert.NotNil(t, schema.Validate(map[string]any{"results": map[string]any{"tests": []any{}}}))
+
+	var typed struct {
+		Results struct {
+			Summary struct {
+				Tests, Passed, Failed, Start, Stop int64
+				Extra                              map[string]any
+			}
+			Tests []struct {
+				Name, Status, Suite, Message, Trace, RawStatus, FilePath string
+				Duration, Start, Line, Retries                           int64
+				Flaky                                                    bool
+			}
+		}
+	}
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &typed))
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	summary := typed.Results.Summary
+	assert.Equal(t, int64(3), summary.Tests)
+	assert.Equal(t, int64(2), summary.Passed)
+	assert.Equal(t, int64(1), summary.Failed)
+	assert.Equal(t, start, summary.Start)
+	assert.Equal(t, start+3000, summary.Stop)
+	assert.Equal(t, 1.0, summary.Extra["flaky"])
+
+	tests := typed.Results.Tests
+	require.Len(t, tests, 3)
+	assert.Equal(t, "TestA", tests[0].Name)
+	assert.Equal(t, "passed", tests[0].Status)
+	assert.Equal(t, "flaky", tests[0].RawStatus)
+	assert.Equal(t, int64(1), tests[0].Retries)
+	assert.True(t, tests[0].Flaky)
+	assert.Equal(t, "TestA/sub", tests[1].Name)
+	assert.Equal(t, "failed", tests[1].Status)
+	assert.Equal(t, "Should be true", tests[1].Message)
+	assert.Equal(t, "a_test.go:12", tests[1].Trace)
+	assert.Equal(t, "pkg/a/a_test.go", tests[1].FilePath)
+	assert.Equal(t, int64(12), tests[1].Line)
+	assert.Equal(t, int64(1000), tests[1].Duration)
+	assert.Equal(t, start+1000, tests[1].Start)
+	assert.Equal(t, "example.com/a", tests[1].Suite)
+}
@@ -0,0 +1,209 @@
+package format
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// failureKind is the cause of a test or package failure.
+type failureKind string
+
+const (
+	kindAssertion  failureKind = "assertion"
+	kindPanic      failureKind = "panic"
+	kindTimeout    failureKind = "timeout"
+	kindDataRace   failureKind = "data-race"
+	kindBuildError failureKind = "build-error"
+)
+
+var failureKinds = []failureKind{kindAssertion, kindPanic, kindTimeout, kindDataRace, kindBuildError}
+
+var (
+	// compiler errors such as "pkg/a.go:3:23: undefined: x"
+	buildErrorRegex = regexp.MustCompile(`^\s*(\S+\.go):(\d+)(?::\d+)?: (.*)$`)
+	// stack frames such as "	/home/user/pkg/a_test.go:12 +0x1d"
+	stackFrameRegex = regexp.MustCompile(`^\s+(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// failure is a failed test or a package which failed without failed tests, for example because it did not build.
+type failure struct {
+	pack     report.PackageResult
+	test     *report.TestResult // nil if the package failed
+	kind     failureKind
+	message  string // short description of the failure
+	output   string
+	file     string // slash separated path relative to the working directory. Empty if unknown
+	line     int
+	severity string // error for persistent failures, warning for quarantined and note for flaky tests
+}
+
+// name is the test name or the package name of package failures.
+func (f failure) name() string {
+	if f.test == nil {
+		return string(f.pack.Name)
+	}
+	return f.test.Name
+}
+
+// fingerprint is a stable identifier of the failure which does not change between runs.
+func (f failure) fingerprint() string {
+	hash := sha256.Sum256([]byte(string(f.pack.Name) + "\x00" + f.name() + "\x00" + string(f.kind)))
+	return hex.EncodeToString(hash[:16])
+}
+
+func joinOutput(lines []report.OutputLine) string {
+	output := strings.Builder{}
+	for _, line := range lines {
+		output.WriteString(line.Text)
+	}
+	return output.String()
+}
+
+func classify(output string, incomplete bool) failureKind {
+	switch {
+	case strings.Contains(output, "WARNING: DATA RACE") || strings.Contains(output, "race detected during execution of test"):
+		return kindDataRace
+	case strings.Contains(output, "panic: test timed out"):
+		return kindTimeout
+	case strings.Contains(output, "panic: "):
+		return kindPanic
+	case incomplete:
+		return kindTimeout
+	default:
+		return kindAssertion
+	}
+}
+
+// firstLine returns the first trimmed line which contains the text or the first non empty line
+// which is no test framing such as "=== RUN" if text is empty.
+func firstLine(output, text string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if text != "" && strings.Contains(line, text) {
+			return line
+		}
+		if text == "" && line != "" && !strings.HasPrefix(line, "=== ") && !strings.HasPrefix(line, "--- ") {
+			return line
+		}
+	}
+	return ""
+}
+
+// stackLocation returns the first frame of a panic stack trace inside of the working directory.
+func stackLocation(output string) (file string, line int) {
+	for _, text := range strings.Split(output, "\n") {
+		match := stackFrameRegex.FindStringSubmatch(text)
+		if match == nil || !filepath.IsAbs(match[1]) {
+			continue
+		}
+		rel, ok := report.RelativeFile(match[1])
+		if !ok {
+			continue
+		}
+		line, _ = strconv.Atoi(match[2])
+		return rel, line
+	}
+	return "", 0
+}
+
+// leafTest is true if the test failed or did not finish, but none of its subtests did. Parent tests which
+// only failed because of a subtest are no leaves.
+func leafTest(pack report.PackageResult, test report.TestResult) bool {
+	if test.TestResult != report.FTSFail && test.TestResult != report.FTSIncomplete {
+		return false
+	}
+	for _, other := range pack.Tests {
+		if other.TestResult == test.TestResult && strings.HasPrefix(other.Name, test.Name+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// failures returns the failed leaf tests and packages which failed without failed tests.
+func failures(result report.Result) (res []failure) {
+	for pIdx := range result.PackageResult {
+		pack := result.PackageResult[pIdx]
+		packageOutput := joinOutput(pack.Output)
+		failedTests := 0
+		for tIdx := range pack.Tests {
+			test := &pack.Tests[tIdx]
+			if test.TestResult == report.FTSFail || test.TestResult == report.FTSIncomplete {
+				failedTests++
+			}
+			if !leafTest(pack, *test) {
+				continue
+			}
+			output := joinOutput(test.Output)
+			classified := output
+			if test.TestResult == report.FTSIncomplete {
+				// The timeout panic is printed after the test output
+				classified += packageOutput
+			}
+			f := failure{pack: pack, test: test, kind: classify(classified, test.TestResult == report.FTSIncomplete), output: output, severity: "error"}
+			switch {
+			case test.Flaky():
+				f.severity = "note"
+			case test.Quarantine != nil:
+				f.severity = "warning"
+			}
+			switch f.kind {
+			case kindDataRace:
+				f.message = "data race detected"
+			case kindTimeout:
+				if f.message = firstLine(classified, "panic: test timed out"); f.message == "" {
+					f.message = "test did not finish"
+				}
+			case kindPanic:
+				f.message = firstLine(output, "panic: ")
+				f.file, f.line = stackLocation(output)
+			default:
+				if len(test.Assertions) > 0 {
+					f.message = test.Assertions[0].Message
+				} else {
+					f.message = firstLine(output, "")
+				}
+			}
+			if f.message == "" {
+				f.message = "test failed"
+			}
+			if locations := test.Locations(); f.file == "" && len(locations) > 0 {
+				if file, ok := pack.SourceFile(locations[0]); ok {
+					f.file, f.line = file, locations[0].Line
+				}
+			}
+			res = append(res, f)
+		}
+		if pack.PackageResult != report.FTSFail || failedTests > 0 {
+			continue
+		}
+		f := failure{pack: pack, kind: classify(packageOutput, false), output: packageOutput, severity: "error"}
+		if f.kind == kindAssertion {
+			f.kind = kindBuildError
+		}
+		for _, text := range strings.Split(packageOutput, "\n") {
+			if match := buildErrorRegex.FindStringSubmatch(text); match != nil {
+				f.file = filepath.ToSlash(filepath.Clean(match[1]))
+				f.line, _ = strconv.Atoi(match[2])
+				f.message = strings.TrimSpace(text)
+				break
+			}
+		}
+		if f.kind == kindPanic {
+			f.file, f.line = stackLocation(packageOutput)
+		}
+		if f.message == "" {
+			if f.message = firstLine(packageOutput, "panic: "); f.message == "" {
+				f.message = "package " + string(pack.Name) + " failed"
+			}
+		}
+		res = append(res, f)
+	}
+	return res
+}
@@ -0,0 +1,221 @@
+package format
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// testRow is a single run of a test. Every rerun of a test is another row.
+type testRow struct {
+	runID       string
+	pack        report.PackageName
+	test        string
+	parent      string
+	status      report.FinalTestStatus
+	result      string // final result of the test, which is flaky if it passed on a rerun
+	duration    time.Duration
+	start       time.Time
+	message     string
+	attempt     int // zero for the first run
+	quarantined bool
+}
+
+// column of the flat formats. The value is a string, number, bool or nil.
+type column struct {
+	name  string
+	value func(row testRow) any
+}
+
+// columns are all columns in their default order.
+var columns = []column{
+	{"run_id", func(row testRow) any { return row.runID }},
+	{"package", func(row testRow) any { return string(row.pack) }},
+	{"test", func(row testRow) any { return row.test }},
+	{"parent", func(row testRow) any { return row.parent }},
+	{"status", func(row testRow) any { return row.status.String() }},
+	{"result", func(row testRow) any { return row.result }},
+	{"duration", func(row testRow) any { return row.duration.Seconds() }},
+	{"start", func(row testRow) any {
+		if row.start.IsZero() {
+			return nil
+		}
+		return row.start.UTC().Format(time.RFC3339Nano)
+	}},
+	{"message", func(row testRow) any { return row.message }},
+	{"attempt", func(row testRow) any { return row.attempt }},
+	{"quarantined", func(row testRow) any { return row.quarantined }},
+}
+
+// ColumnNames returns the names of all columns of the csv and ndjson formats in their default order.
+func ColumnNames() []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// ParseColumns parses a comma separated list of columns. All columns are returned if the list is empty.
+func ParseColumns(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return ColumnNames(), nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if _, err := getColumn(name); err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %s", name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func getColumn(name string) (column, error) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, nil
+		}
+	}
+	return column{}, fmt.Errorf("unknown column %s. Expected one of %s", name, strings.Join(ColumnNames(), ", "))
+}
+
+// selectColumns returns the columns of the options or all columns if none are set.
+func selectColumns(opts Options) ([]column, error) {
+	if len(opts.Columns) == 0 {
+		return columns, nil
+	}
+	selected := make([]column, 0, len(opts.Columns))
+	for _, name := range opts.Columns {
+		c, err := getColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// runMessage returns the failure message of a failed or incomplete run.
+func runMessage(status report.FinalTestStatus, output []report.OutputLine) string {
+	if status != report.FTSFail && status != report.FTSIncomplete {
+		return ""
+	}
+	message, _ := failureMessage(report.TestResult{Output: output, Assertions: report.ParseAssertions(output)})
+	if message == "" && status == report.FTSIncomplete {
+		message = "test did not finish"
+	}
+	return message
+}
+
+// testRows flattens the result into a row for every run of every test. Packages which failed without failed tests,
+// such as on build errors, are a row without test.
+func testRows(result report.Result) (rows []testRow) {
+	runID := result.Vars["RunID"]
+	for _, pack := range result.PackageResult {
+		names := make(map[string]bool, len(pack.Tests))
+		for _, test := range pack.Tests {
+			names[test.Name] = true
+		}
+		for _, test := range pack.Tests {
+			row := testRow{runID: runID, pack: pack.Name, test: test.Name, parent: report.ParentTest(names, test.Name), status: test.TestResult, result: test.Classification(),
+				duration: test.Duration, start: test.Started, quarantined: test.Quarantine != nil}
+			row.message = runMessage(test.TestResult, test.Output)
+			rows = append(rows, row)
+			for idx, attempt := range test.Attempts {
+				rows = append(rows, testRow{runID: runID, pack: pack.Name, test: test.Name, parent: row.parent, status: attempt.TestResult, result: row.result,
+					duration: attempt.Duration, start: attempt.Started, message: runMessage(attempt.TestResult, attempt.Output), attempt: idx + 1, quarantined: row.quarantined})
+			}
+		}
+		for _, f := range failures(report.Result{PackageResult: []report.PackageResult{pack}}) {
+			if f.test == nil {
+				rows = append(rows, testRow{runID: runID, pack: pack.Name, status: report.FTSFail, result: "failed",
+					duration: pack.Duration, start: pack.Started, message: f.message})
+			}
+		}
+	}
+	return rows
+}
+
+// csvValue formats the value of a column.
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// CSV writes a header and a row for every run of every test. Reruns are rows with an attempt greater than zero.
+// The run_id column is the RunID variable.
+func CSV(result report.Result, out io.Writer, opts Options) error {
+	selected, err := selectColumns(opts)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	record := make([]string, len(selected))
+	for idx, c := range selected {
+		record[idx] = c.name
+	}
+	if err := w.Write(record); err != nil {
+		return err
+	}
+	for _, row := range testRows(result) {
+		for idx, c := range selected {
+			record[idx] = csvValue(c.value(row))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// NDJSON writes a JSON object for every run of every test per line, as loaded by BigQuery and most log tools.
+// The keys are written in the order of the columns.
+func NDJSON(result report.Result, out io.Writer, opts Options) error {
+	selected, err := selectColumns(opts)
+	if err != nil {
+		return err
+	}
+	buff := bufio.NewWriter(out)
+	for _, row := range testRows(result) {
+		line := []byte{'{'}
+		for idx, c := range selected {
+			if idx > 0 {
+				line = append(line, ',')
+			}
+			key, _ := json.Marshal(c.name)
+			value, err := json.Marshal(c.value(row))
+			if err != nil {
+				return err
+			}
+			line = append(append(append(line, key...), ':'), value...)
+		}
+		line = append(line, '}', '\n')
+		if _, err := buff.Write(line); err != nil {
+			return err
+		}
+	}
+	return buff.Flush()
+}
@@ -0,0 +1,113 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var flatResult = report.Result{Vars: map[string]string{"RunID": "42"}, PackageResult: []report.PackageResult{
+	{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "TestA", TestResult: report.FTSPass, Duration: 1500 * time.Millisecond, Started: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{Name: "TestA/sub", TestResult: report.FTSFail, Duration: time.Second, Output: output("    a_test.go:12: want 1, got 2\n", "--- FAIL: TestA/sub (1.00s)\n"),
+			Attempts: []report.Attempt{{TestResult: report.FTSPass, Duration: 2 * time.Second, Started: time.Date(2024, 1, 2, 3, 5, 0, 0, time.UTC)}}},
+		{Name: "TestB", TestResult: report.FTSIncomplete, Quarantine: &report.QuarantineEntry{}},
+	}},
+	{Name: "example.com/bad", PackageResult: report.FTSFail, Output: output("bad/bad.go:3:23: undefined: x\n")},
+}}
+
+func TestCSV(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.CSV(flatResult, buff, format.Options{}))
+
+	records, err := csv.NewReader(buff).ReadAll()
+	require.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"run_id", "package", "test", "parent", "status", "result", "duration", "start", "message", "attempt", "quarantined"},
+		{"42", "example.com/a", "TestA", "", "pass", "passed", "1.5", "2024-01-02T03:04:05Z", "", "0", "false"},
+		{"42", "example.com/a", "TestA/sub", "TestA", "fail", "flaky", "1", "", "a_test.go:12: want 1, got 2", "0", "false"},
+		{"42", "example.com/a", "TestA/sub", "TestA", "pass", "flaky", "2", "2024-01-02T03:05:00Z", "", "1", "false"},
+		{"42", "example.com/a", "TestB", "", "incomplete", "incomplete", "0", "", "test did not finish", "0", "true"},
+		{"42", "example.com/bad", "", "", "fail", "failed", "0", "", "bad/bad.go:3:23: undefined: x", "0", "false"},
+	}, records)
+}
+
+func TestCSV_Columns(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.CSV(flatResult, buff, format.Options{Columns: []string{"test", "attempt", "status"}}))
+
+	assert.Equal(t, "test,attempt,status\nTestA,0,pass\nTestA/sub,0,fail\nTestA/sub,1,pass\nTestB,0,incomplete\n,0,fail\n", buff.String())
+	assert.NotNil(t, format.CSV(flatResult, buff, format.Options{Columns: []string{"foo"}}))
+}
+
+func TestCSV_Parent(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+	result := report.Result{PackageResult: []report.PackageResult{{Name: "example.com/a", PackageResult: report.FTSPass, Tests: []report.TestResult{
+		{Name: "TestA"}, {Name: "TestA/example.com/a"}, {Name: "TestA/example.com/a/#00"}, {Name: "TestB/sub/case"},
+	}}}}
+
+	require.Nil(t, format.CSV(result, buff, format.Options{Columns: []string{"test", "parent"}}))
+
+	assert.Equal(t, "test,parent\nTestA,\nTestA/example.com/a,TestA\nTestA/example.com/a/#00,TestA/example.com/a\nTestB/sub/case,TestB/sub\n", buff.String())
+}
+
+func TestNDJSON(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.NDJSON(flatResult, buff, format.Options{}))
+
+	lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, `{"run_id":"42","package":"example.com/a","test":"TestA","parent":"","status":"pass","result":"passed","duration":1.5,`+
+		`"start":"2024-01-02T03:04:05Z","message":"","attempt":0,"quarantined":false}`, lines[0])
+	var rerun map[string]any
+	require.Nil(t, json.Unmarshal([]byte(lines[2]), &rerun))
+	assert.Equal(t, "TestA/sub", rerun["test"])
+	assert.Equal(t, float64(1), rerun["attempt"])
+	assert.Equal(t, "pass", rerun["status"])
+	assert.Equal(t, "2024-01-02T03:05:00Z", rerun["start"])
+}
+
+func TestNDJSON_Columns(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.NDJSON(flatResult, buff, format.Options{Columns: []string{"message", "package"}}))
+
+	assert.Equal(t, `{"message":"","package":"example.com/a"}`, strings.Split(buff.String(), "\n")[0])
+}
+
+func TestParseColumns(t *testing.T) {
+	var suite = []struct {
+		in      string
+		columns []string
+		isErr   bool
+	}{
+		{"", format.ColumnNames(), false},
+		{"test, status", []string{"test", "status"}, false},
+		{"attempt", []string{"attempt"}, false},
+		{"test,foo", nil, true},
+		{"test,test", nil, true},
+		{"test,", nil, true},
+	}
+	for _, s := range suite {
+		t.Run(s.in, func(t *testing.T) {
+			columns, err := format.ParseColumns(s.in)
+			if s.isErr {
+				assert.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+				assert.Equal(t, s.columns, columns)
+			}
+		})
+	}
+}
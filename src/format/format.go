@@ -0,0 +1,92 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Options configure the output formats.
+type Options struct {
+	// MaxTests limits the number of tests with their own metrics to the slowest ones. Zero means no limit.
+	MaxTests int
+	// Columns of the csv and ndjson formats in their order. All columns are written if empty.
+	Columns []string
+}
+
+// Writer writes the test result in a machine readable format.
+type Writer func(result report.Result, out io.Writer, opts Options) error
+
+// Writers are the output formats which can be selected instead of a template.
+var Writers = map[string]Writer{
+	"chrome-trace": ChromeTrace,
+	"codequality":  CodeQuality,
+	"csv":          CSV,
+	"ctrf":         CTRF,
+	"junit":        JUnit,
+	"ndjson":       NDJSON,
+	"openmetrics":  OpenMetrics,
+	"otlp":         OTLP,
+	"sarif":        SARIF,
+	"slack":        Slack,
+	"tap":          TAP,
+	"teamcity":     TeamCity,
+	"teams":        Teams,
+}
+
+// Names returns the sorted names of all formats.
+func Names() []string {
+	names := make([]string, 0, len(Writers))
+	for name := range Writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the writer of the format.
+func Get(name string) (Writer, error) {
+	writer, ok := Writers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %s. Expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return writer, nil
+}
+
+// withoutURL removes the URL from errors of the http client, because URLs of webhooks contain secrets.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// post sends the JSON body to the endpoint. Responses without a 2xx status are errors.
+// The endpoint is not part of the error.
+func post(client *http.Client, endpoint string, headers map[string]string, body io.Reader) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, body)
+	if err != nil {
+		return withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s. %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
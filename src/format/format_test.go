@@ -0,0 +1,19 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	for _, name := range format.Names() {
+		writer, err := format.Get(name)
+		assert.Nil(t, err)
+		assert.NotNil(t, writer)
+	}
+
+	_, err := format.Get("foo")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Code Quality report of GitLab, a subset of the CodeClimate format
+// https://docs.gitlab.com/ee/ci/testing/code_quality.html#code-quality-report-format
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeQualitySeverity maps the severity of the failure. Build errors and data races are critical.
+func codeQualitySeverity(f failure) string {
+	switch {
+	case f.severity == "note":
+		return "info"
+	case f.severity == "warning":
+		return "minor"
+	case f.kind == kindBuildError || f.kind == kindDataRace:
+		return "critical"
+	default:
+		return "major"
+	}
+}
+
+// CodeQuality writes every failed test and package which failed without failed tests as issue of a GitLab Code
+// Quality report. Issues of failures without known location point to the directory of the package. Failures
+// of flaky tests are info and failures of quarantined tests are minor issues.
+func CodeQuality(result report.Result, out io.Writer, _ Options) error {
+	issues := []codeQualityIssue{}
+	for _, f := range failures(result) {
+		description := f.message
+		if f.test != nil {
+			description = fmt.Sprintf("%s: %s", f.test.Name, description)
+			if f.test.Flaky() {
+				description += fmt.Sprintf(" (flaky, passed on rerun %d)", len(f.test.Attempts))
+			} else if len(f.test.Attempts) > 0 {
+				description += fmt.Sprintf(" (failed %d reruns)", len(f.test.Attempts))
+			}
+		}
+		location := codeQualityLocation{Path: f.file, Lines: codeQualityLines{Begin: f.line}}
+		if location.Path == "" {
+			location.Path = f.pack.Dir
+			if location.Path == "" {
+				location.Path = "."
+			}
+		}
+		if location.Lines.Begin <= 0 {
+			location.Lines.Begin = 1
+		}
+		issues = append(issues, codeQualityIssue{
+			Description: fmt.Sprintf("%s %s", f.pack.Name, description),
+			CheckName:   "go-test/" + string(f.kind),
+			Fingerprint: f.fingerprint(),
+			Severity:    codeQualitySeverity(f),
+			Location:    location,
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(issues)
+}
+
+// GitLab report files which are written by WriteGitLab
+const (
+	GitLabJUnitFile       = "junit.xml"
+	GitLabCodeQualityFile = "gl-code-quality-report.json"
+)
+
+// WriteGitLab writes the JUnit report for the test widget and the Code Quality report for the merge request
+// diff into the directory.
+func WriteGitLab(result report.Result, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	files := []struct {
+		name  string
+		write Writer
+	}{
+		{GitLabJUnitFile, JUnit},
+		{GitLabCodeQualityFile, CodeQuality},
+	}
+	for _, file := range files {
+		write := file.write
+		if err := writeFile(dir, file.name, func(out io.Writer) error { return write(result, out, Options{}) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
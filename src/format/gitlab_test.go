@@ -0,0 +1,115 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codeQualityIssue struct {
+	Description string `json:"description"`
+	CheckName   string `json:"check_name"`
+	Fingerprint string `json:"fingerprint"`
+	Severity    string `json:"severity"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+	} `json:"location"`
+}
+
+func TestCodeQuality(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.CodeQuality(junitResult, buff, format.Options{}))
+
+	var issues []codeQualityIssue
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &issues))
+	suite := []struct {
+		description, check, severity, path string
+		line                               int
+	}{
+		{"example.com/a TestFail: a_test.go:10: Not equal \x1b[31mred\x1b[0m (failed 1 reruns)", "go-test/assertion", "major", "a/a_test.go", 10},
+		{"example.com/a TestFlaky: a_test.go:20: flaky (flaky, passed on rerun 2)", "go-test/assertion", "info", "a/a_test.go", 20},
+		{"example.com/a TestTimeout: panic: test timed out after 1s", "go-test/timeout", "major", "a", 1},
+		{"example.com/bad bad/bad.go:3:23: undefined: x", "go-test/build-error", "critical", "bad/bad.go", 3},
+	}
+	require.Len(t, issues, len(suite))
+	fingerprints := make(map[string]bool)
+	for idx, s := range suite {
+		t.Run(s.description, func(t *testing.T) {
+			issue := issues[idx]
+			assert.Equal(t, s.description, issue.Description)
+			assert.Equal(t, s.check, issue.CheckName)
+			assert.Equal(t, s.severity, issue.Severity)
+			assert.Equal(t, s.path, issue.Location.Path)
+			assert.Equal(t, s.line, issue.Location.Lines.Begin)
+			assert.Len(t, issue.Fingerprint, 32)
+			assert.False(t, fingerprints[issue.Fingerprint], "fingerprints are unique")
+			fingerprints[issue.Fingerprint] = true
+		})
+	}
+}
+
+func TestCodeQuality_AbsoluteErrorTrace(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	require.Nil(t, err)
+	var suite = []struct {
+		file string
+		path string
+		line int
+	}{
+		{filepath.Join(wd, "a", "x_test.go"), "a/x_test.go", 12},
+		{filepath.Join(filepath.Dir(wd), "x_test.go"), "a", 1},
+	}
+	for _, s := range suite {
+		t.Run(s.file, func(t *testing.T) {
+			buff := bytes.NewBuffer(nil)
+
+			require.Nil(t, format.CodeQuality(errorTraceResult(s.file), buff, format.Options{}))
+
+			var issues []codeQualityIssue
+			require.Nil(t, json.Unmarshal(buff.Bytes(), &issues))
+			require.Len(t, issues, 1)
+			assert.Equal(t, s.path, issues[0].Location.Path)
+			assert.Equal(t, s.line, issues[0].Location.Lines.Begin)
+		})
+	}
+}
+
+func TestCodeQuality_NoFailures(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.CodeQuality(report.Result{}, buff, format.Options{}))
+
+	assert.Equal(t, "[]\n", buff.String())
+}
+
+func TestWriteGitLab(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+
+	require.Nil(t, format.WriteGitLab(junitResult, dir))
+
+	junit, err := os.ReadFile(filepath.Join(dir, format.GitLabJUnitFile))
+	require.Nil(t, err)
+	var suites struct {
+		Tests int `xml:"tests,attr"`
+	}
+	require.Nil(t, xml.Unmarshal(junit, &suites))
+	assert.Equal(t, 6, suites.Tests)
+
+	codeQuality, err := os.ReadFile(filepath.Join(dir, format.GitLabCodeQualityFile))
+	require.Nil(t, err)
+	var issues []codeQualityIssue
+	require.Nil(t, json.Unmarshal(codeQuality, &issues))
+	assert.Len(t, issues, 4)
+}
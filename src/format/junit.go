@@ -0,0 +1,202 @@
+package format
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// JUnit XML as understood by GitLab, Jenkins and the Maven Surefire plugin, which defines the elements for reruns
+// https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report.xsd
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name          string        `xml:"name,attr"`
+	Classname     string        `xml:"classname,attr"`
+	Time          string        `xml:"time,attr"`
+	File          string        `xml:"file,attr,omitempty"`
+	Line          int           `xml:"line,attr,omitempty"`
+	Failure       *junitFailure `xml:"failure"`
+	Error         *junitFailure `xml:"error"`
+	Skipped       *junitSkipped `xml:"skipped"`
+	FlakyFailures []junitRerun  `xml:"flakyFailure"`
+	FlakyErrors   []junitRerun  `xml:"flakyError"`
+	RerunFailures []junitRerun  `xml:"rerunFailure"`
+	RerunErrors   []junitRerun  `xml:"rerunError"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+type junitRerun struct {
+	Message    string      `xml:"message,attr"`
+	Type       string      `xml:"type,attr"`
+	StackTrace *junitCData `xml:"stackTrace"`
+}
+
+type junitCData struct {
+	Text string `xml:",cdata"`
+}
+
+// xmlText removes characters which are not allowed in XML documents, such as the escape character of colored output.
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) || r >= 0x10000 {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// junitRun returns the failure of a failed or incomplete run of a test and whether it is an error.
+func junitRun(status report.FinalTestStatus, output []report.OutputLine, message string) (failure junitRerun, isError bool) {
+	text := joinOutput(output)
+	failure = junitRerun{Message: message, Type: string(classify(text, status == report.FTSIncomplete)), StackTrace: &junitCData{Text: xmlText(text)}}
+	return failure, status == report.FTSIncomplete
+}
+
+// newJUnitTestCase converts the test. Tests which passed on a rerun pass with their failed runs as flaky failures.
+// The failed reruns of other failed tests are rerun failures.
+func newJUnitTestCase(pack report.PackageResult, test report.TestResult) junitTestCase {
+	testCase := junitTestCase{Name: test.Name, Classname: string(pack.Name), Time: seconds(test.Duration)}
+	if locations := test.Locations(); len(locations) > 0 {
+		if file, ok := pack.SourceFile(locations[0]); ok {
+			testCase.File, testCase.Line = file, locations[0].Line
+		}
+	}
+
+	type run struct {
+		status  report.FinalTestStatus
+		output  []report.OutputLine
+		message string
+	}
+	runs := []run{{test.TestResult, test.Output, testMessage(test)}}
+	for idx, attempt := range test.Attempts {
+		message := fmt.Sprintf("failed on rerun %d", idx+1)
+		if attempt.TestResult == report.FTSIncomplete {
+			message = fmt.Sprintf("did not finish on rerun %d", idx+1)
+		}
+		runs = append(runs, run{attempt.TestResult, attempt.Output, message})
+	}
+
+	switch {
+	case test.Flaky():
+		for _, r := range runs {
+			if r.status != report.FTSFail && r.status != report.FTSIncomplete {
+				continue
+			}
+			failure, isError := junitRun(r.status, r.output, r.message)
+			if isError {
+				testCase.FlakyErrors = append(testCase.FlakyErrors, failure)
+			} else {
+				testCase.FlakyFailures = append(testCase.FlakyFailures, failure)
+			}
+		}
+	case test.TestResult == report.FTSFail || test.TestResult == report.FTSIncomplete:
+		failure, isError := junitRun(test.TestResult, test.Output, runs[0].message)
+		if isError {
+			testCase.Error = &junitFailure{Message: failure.Message, Type: failure.Type, Text: failure.StackTrace.Text}
+		} else {
+			testCase.Failure = &junitFailure{Message: failure.Message, Type: failure.Type, Text: failure.StackTrace.Text}
+		}
+		for _, r := range runs[1:] {
+			if r.status != report.FTSFail && r.status != report.FTSIncomplete {
+				continue
+			}
+			failure, isError := junitRun(r.status, r.output, r.message)
+			if isError {
+				testCase.RerunErrors = append(testCase.RerunErrors, failure)
+			} else {
+				testCase.RerunFailures = append(testCase.RerunFailures, failure)
+			}
+		}
+	case test.TestResult == report.FTPSSkip:
+		testCase.Skipped = &junitSkipped{Message: firstLine(joinOutput(test.Output), "")}
+	}
+	return testCase
+}
+
+// JUnit writes the result as JUnit XML with a test suite for every package. Tests which passed on a rerun
+// pass and contain their failed runs as flaky failures. Packages which failed without failed tests, such
+// as build errors, are reported as a test case with an error.
+func JUnit(result report.Result, out io.Writer, _ Options) error {
+	suites := junitTestSuites{Name: "go test", Time: seconds(result.CPUTime())}
+	for _, pack := range result.PackageResult {
+		suite := junitTestSuite{Name: string(pack.Name), Time: seconds(pack.Duration)}
+		if !pack.Started.IsZero() {
+			suite.Timestamp = pack.Started.UTC().Format("2006-01-02T15:04:05")
+		}
+		for _, test := range pack.Tests {
+			suite.Cases = append(suite.Cases, newJUnitTestCase(pack, test))
+		}
+		for _, f := range failures(report.Result{PackageResult: []report.PackageResult{pack}}) {
+			if f.test == nil {
+				testCase := junitTestCase{Name: f.name(), Classname: string(pack.Name), Time: seconds(0), File: f.file, Line: f.line,
+					Error: &junitFailure{Message: f.message, Type: string(f.kind), Text: xmlText(f.output)}}
+				suite.Cases = append(suite.Cases, testCase)
+			}
+		}
+		for _, testCase := range suite.Cases {
+			suite.Tests++
+			switch {
+			case testCase.Failure != nil:
+				suite.Failures++
+			case testCase.Error != nil:
+				suite.Errors++
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			}
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
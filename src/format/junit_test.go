@@ -0,0 +1,72 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var junitResult = report.Result{PackageResult: []report.PackageResult{
+	{Name: "example.com/a", Dir: "a", Duration: 1500 * time.Millisecond, PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "TestFail", Duration: 1200 * time.Millisecond, TestResult: report.FTSFail,
+			Output:   output("    a_test.go:10: Not equal \x1b[31mred\x1b[0m"),
+			Attempts: []report.Attempt{{TestResult: report.FTSFail, Output: output("    a_test.go:10: again")}}},
+		{Name: "TestFlaky", TestResult: report.FTSFail, Output: output("    a_test.go:20: flaky"),
+			Attempts: []report.Attempt{{TestResult: report.FTSIncomplete}, {TestResult: report.FTSPass}}},
+		{Name: "TestTimeout", TestResult: report.FTSIncomplete},
+		{Name: "TestSkip", TestResult: report.FTPSSkip, Output: output("    a_test.go:30: not on CI", "--- SKIP: TestSkip (0.00s)")},
+		{Name: "TestPass", TestResult: report.FTSPass},
+	}, Output: output("panic: test timed out after 1s")},
+	{Name: "example.com/bad", PackageResult: report.FTSFail, Output: output("bad/bad.go:3:23: undefined: x")},
+}}
+
+func TestJUnit(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.JUnit(junitResult, buff, format.Options{}))
+
+	assert.True(t, strings.HasPrefix(buff.String(), xml.Header))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="go test" tests="6" failures="1" errors="2" skipped="1" time="1.500">
+  <testsuite name="example.com/a" tests="5" failures="1" errors="1" skipped="1" time="1.500">
+    <testcase name="TestFail" classname="example.com/a" time="1.200" file="a/a_test.go" line="10">
+      <failure message="a_test.go:10: Not equal `+"\uFFFD"+`[31mred`+"\uFFFD"+`[0m" type="assertion"><![CDATA[    a_test.go:10: Not equal [31mred[0m
+]]></failure>
+      <rerunFailure message="failed on rerun 1" type="assertion">
+        <stackTrace><![CDATA[    a_test.go:10: again
+]]></stackTrace>
+      </rerunFailure>
+    </testcase>
+    <testcase name="TestFlaky" classname="example.com/a" time="0.000" file="a/a_test.go" line="20">
+      <flakyFailure message="a_test.go:20: flaky" type="assertion">
+        <stackTrace><![CDATA[    a_test.go:20: flaky
+]]></stackTrace>
+      </flakyFailure>
+      <flakyError message="did not finish on rerun 1" type="timeout">
+        <stackTrace></stackTrace>
+      </flakyError>
+    </testcase>
+    <testcase name="TestTimeout" classname="example.com/a" time="0.000">
+      <error message="test did not finish" type="timeout"></error>
+    </testcase>
+    <testcase name="TestSkip" classname="example.com/a" time="0.000" file="a/a_test.go" line="30">
+      <skipped message="a_test.go:30: not on CI"></skipped>
+    </testcase>
+    <testcase name="TestPass" classname="example.com/a" time="0.000"></testcase>
+  </testsuite>
+  <testsuite name="example.com/bad" tests="1" failures="0" errors="1" skipped="0" time="0.000">
+    <testcase name="example.com/bad" classname="example.com/bad" time="0.000" file="bad/bad.go" line="3">
+      <error message="bad/bad.go:3:23: undefined: x" type="build-error"><![CDATA[bad/bad.go:3:23: undefined: x
+]]></error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buff.String())
+}
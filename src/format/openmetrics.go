@@ -0,0 +1,119 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsWriter writes OpenMetrics metric families. The first write error is kept.
+type metricsWriter struct {
+	out *bufio.Writer
+	err error
+}
+
+func (w *metricsWriter) printf(format string, a ...any) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintf(w.out, format, a...)
+	}
+}
+
+// family writes the metadata of a gauge. Names ending with _seconds get the seconds unit.
+func (w *metricsWriter) family(name, help string) {
+	w.printf("# TYPE %s gauge\n", name)
+	if strings.HasSuffix(name, "_seconds") {
+		w.printf("# UNIT %s seconds\n", name)
+	}
+	w.printf("# HELP %s %s\n", name, help)
+}
+
+// sample writes a value with labels given as alternating names and values.
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.printf("%s", name)
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for idx := 0; idx+1 < len(labels); idx += 2 {
+			pairs = append(pairs, labels[idx]+`="`+labelEscaper.Replace(labels[idx+1])+`"`)
+		}
+		w.printf("{%s}", strings.Join(pairs, ","))
+	}
+	w.printf(" %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// OpenMetrics writes gauges for the test counts and durations in the OpenMetrics text format which can
+// be read by the textfile collector of the Prometheus node exporter. If MaxTests is set, only the
+// durations of the slowest tests are written to limit the number of time series.
+func OpenMetrics(result report.Result, out io.Writer, opts Options) error {
+	w := &metricsWriter{out: bufio.NewWriter(out)}
+
+	counts := []struct {
+		name, help string
+		value      uint
+	}{
+		{"go_test_tests", "Number of tests.", result.Tests},
+		{"go_test_passed", "Number of passed tests.", result.Passed},
+		{"go_test_failed", "Number of failed tests.", result.Failed},
+		{"go_test_skipped", "Number of skipped tests.", result.Skipped},
+		{"go_test_incomplete", "Number of tests which did not finish.", result.Incomplete},
+		{"go_test_flaky", "Number of failed tests which passed on a rerun.", result.Flaky},
+	}
+	for _, count := range counts {
+		w.family(count.name, count.help)
+		w.sample(count.name, float64(count.value))
+	}
+
+	w.family("go_test_duration_seconds", "Sum of the elapsed times of all packages.")
+	w.sample("go_test_duration_seconds", result.CPUTime().Seconds())
+	if span := result.Span(); !span.End.IsZero() {
+		w.family("go_test_wall_time_seconds", "Time from the first to the last test event.")
+		w.sample("go_test_wall_time_seconds", result.WallTime().Seconds())
+		w.family("go_test_last_run_timestamp_seconds", "Unix time of the last test event.")
+		w.sample("go_test_last_run_timestamp_seconds", float64(span.End.UnixNano())/float64(time.Second))
+	}
+
+	w.family("go_test_package_duration_seconds", "Elapsed time of the package.")
+	for _, pack := range result.PackageResult {
+		w.sample("go_test_package_duration_seconds", pack.Duration.Seconds(), "package", string(pack.Name), "status", pack.PackageResult.String())
+	}
+
+	type testOfPackage struct {
+		pack report.PackageName
+		test report.TestResult
+	}
+	var tests []testOfPackage
+	for _, pack := range result.PackageResult {
+		for _, test := range pack.Tests {
+			tests = append(tests, testOfPackage{pack.Name, test})
+		}
+	}
+	if opts.MaxTests > 0 && len(tests) > opts.MaxTests {
+		sort.SliceStable(tests, func(i, j int) bool {
+			return tests[i].test.Duration > tests[j].test.Duration
+		})
+		tests = tests[:opts.MaxTests]
+	}
+	w.family("go_test_test_duration_seconds", "Elapsed time of the test.")
+	for _, t := range tests {
+		w.sample("go_test_test_duration_seconds", t.test.Duration.Seconds(), "package", string(t.pack), "test", t.test.Name, "status", t.test.Classification())
+	}
+	w.family("go_test_test_attempts", "Number of reruns of the failed test.")
+	for _, t := range tests {
+		if len(t.test.Attempts) > 0 {
+			w.sample("go_test_test_attempts", float64(len(t.test.Attempts)), "package", string(t.pack), "test", t.test.Name, "status", t.test.Classification())
+		}
+	}
+
+	w.printf("# EOF\n")
+	if w.err != nil {
+		return w.err
+	}
+	return w.out.Flush()
+}
@@ -0,0 +1,74 @@
+package format_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var metricsResult = report.Result{Tests: 3, Passed: 1, Failed: 2, Flaky: 1, PackageResult: []report.PackageResult{
+	{Name: "example.com/a", Duration: 1500 * time.Millisecond, PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "TestA", Duration: time.Second, TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}},
+		{Name: `Test"B"`, Duration: 250 * time.Millisecond, TestResult: report.FTSPass},
+		{Name: "TestC", Duration: 500 * time.Millisecond, TestResult: report.FTSFail},
+	}},
+}}
+
+func TestOpenMetrics(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.OpenMetrics(metricsResult, buff, format.Options{}))
+
+	assert.Equal(t, `# TYPE go_test_tests gauge
+# HELP go_test_tests Number of tests.
+go_test_tests 3
+# TYPE go_test_passed gauge
+# HELP go_test_passed Number of passed tests.
+go_test_passed 1
+# TYPE go_test_failed gauge
+# HELP go_test_failed Number of failed tests.
+go_test_failed 2
+# TYPE go_test_skipped gauge
+# HELP go_test_skipped Number of skipped tests.
+go_test_skipped 0
+# TYPE go_test_incomplete gauge
+# HELP go_test_incomplete Number of tests which did not finish.
+go_test_incomplete 0
+# TYPE go_test_flaky gauge
+# HELP go_test_flaky Number of failed tests which passed on a rerun.
+go_test_flaky 1
+# TYPE go_test_duration_seconds gauge
+# UNIT go_test_duration_seconds seconds
+# HELP go_test_duration_seconds Sum of the elapsed times of all packages.
+go_test_duration_seconds 1.5
+# TYPE go_test_package_duration_seconds gauge
+# UNIT go_test_package_duration_seconds seconds
+# HELP go_test_package_duration_seconds Elapsed time of the package.
+go_test_package_duration_seconds{package="example.com/a",status="fail"} 1.5
+# TYPE go_test_test_duration_seconds gauge
+# UNIT go_test_test_duration_seconds seconds
+# HELP go_test_test_duration_seconds Elapsed time of the test.
+go_test_test_duration_seconds{package="example.com/a",test="TestA",status="flaky"} 1
+go_test_test_duration_seconds{package="example.com/a",test="Test\"B\"",status="passed"} 0.25
+go_test_test_duration_seconds{package="example.com/a",test="TestC",status="failed"} 0.5
+# TYPE go_test_test_attempts gauge
+# HELP go_test_test_attempts Number of reruns of the failed test.
+go_test_test_attempts{package="example.com/a",test="TestA",status="flaky"} 1
+# EOF
+`, buff.String())
+}
+
+func TestOpenMetrics_MaxTests(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.OpenMetrics(metricsResult, buff, format.Options{MaxTests: 2}))
+
+	assert.Contains(t, buff.String(), `test="TestA"`)
+	assert.Contains(t, buff.String(), `test="TestC"`)
+	assert.NotContains(t, buff.String(), `test="Test\"B\""`)
+}
@@ -0,0 +1,251 @@
+package format
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// OTLP JSON encoding of the trace data
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano uint64          `json:"startTimeUnixNano,string"`
+	EndTimeUnixNano   uint64          `json:"endTimeUnixNano,string"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano uint64          `json:"timeUnixNano,string"`
+	Name         string          `json:"name"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOk         = 1
+	otlpStatusError      = 2
+)
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *int64  `json:"intValue,omitempty,string"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &value}}
+}
+
+func boolAttribute(key string, value bool) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{BoolValue: &value}}
+}
+
+// unixNano returns zero for the zero time, which is before the unix epoch.
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+// spanTimes returns the start and end of a span. A missing start falls back to the start of the parent
+// and a missing end, such as of an incomplete test, to the start.
+func spanTimes(start, end, parentStart time.Time) (time.Time, time.Time) {
+	if start.IsZero() {
+		start = parentStart
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+func otlpStatusOf(status report.FinalTestStatus) otlpStatus {
+	switch status {
+	case report.FTSPass:
+		return otlpStatus{Code: otlpStatusOk}
+	case report.FTSFail:
+		return otlpStatus{Code: otlpStatusError, Message: "failed"}
+	case report.FTSIncomplete:
+		return otlpStatus{Code: otlpStatusError, Message: "incomplete"}
+	default:
+		return otlpStatus{}
+	}
+}
+
+// traceID derives the id from the start of the run and the packages, so that exporting the same
+// result twice results in the same trace.
+func traceID(result report.Result) string {
+	hash := sha256.New()
+	hash.Write([]byte(result.Span().Start.Format(time.RFC3339Nano)))
+	for _, pack := range result.PackageResult {
+		hash.Write([]byte(pack.Name))
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+func spanID(idx uint64) string {
+	id := make([]byte, 8)
+	binary.BigEndian.PutUint64(id, idx)
+	return hex.EncodeToString(id)
+}
+
+// otlp converts the result into a trace with a span for the run, every package and every test.
+// Subtests are children of their parent test. Reruns are events of the test span.
+func otlp(result report.Result) otlpTraces {
+	trace := traceID(result)
+	nextID := uint64(0)
+	newSpan := func(parent, name string, start, end time.Time) otlpSpan {
+		nextID++
+		return otlpSpan{TraceID: trace, SpanID: spanID(nextID), ParentSpanID: parent, Name: name,
+			Kind: otlpSpanKindInternal, StartTimeUnixNano: unixNano(start), EndTimeUnixNano: unixNano(end)}
+	}
+
+	span := result.Span()
+	run := newSpan("", "go test", span.Start, span.End)
+	run.Attributes = []otlpAttribute{
+		intAttribute("go.test.tests", int64(result.Tests)),
+		intAttribute("go.test.passed", int64(result.Passed)),
+		intAttribute("go.test.failed", int64(result.Failed)),
+		intAttribute("go.test.skipped", int64(result.Skipped)),
+		intAttribute("go.test.flaky", int64(result.Flaky)),
+	}
+	run.Status = otlpStatus{Code: otlpStatusOk}
+	if result.PersistentFailures() > 0 {
+		run.Status = otlpStatus{Code: otlpStatusError, Message: fmt.Sprintf("%d tests failed", result.PersistentFailures())}
+	}
+	spans := []otlpSpan{run}
+
+	for _, pack := range result.PackageResult {
+		if pack.Started.IsZero() {
+			continue
+		}
+		packStart, packEnd := spanTimes(pack.Started, pack.Ended, pack.Started)
+		packSpan := newSpan(run.SpanID, string(pack.Name), packStart, packEnd)
+		packSpan.Attributes = []otlpAttribute{stringAttribute("test.suite.name", string(pack.Name))}
+		if pack.Dir != "" {
+			packSpan.Attributes = append(packSpan.Attributes, stringAttribute("code.filepath", pack.Dir))
+		}
+		packSpan.Status = otlpStatusOf(pack.PackageResult)
+		spans = append(spans, packSpan)
+
+		spanOfTest := make(map[string]string)
+		names := make(map[string]bool, len(pack.Tests))
+		testSpans := make([]otlpSpan, 0, len(pack.Tests))
+		for _, test := range pack.Tests {
+			start, end := spanTimes(test.Started, test.Ended, packStart)
+			testSpan := newSpan(packSpan.SpanID, test.Name, start, end)
+			testSpan.Attributes = []otlpAttribute{
+				stringAttribute("test.suite.name", string(pack.Name)),
+				stringAttribute("test.case.name", test.Name),
+				stringAttribute("test.case.result.status", test.TestResult.String()),
+				stringAttribute("go.test.classification", test.Classification()),
+				intAttribute("go.test.duration_ns", int64(test.Duration)),
+			}
+			if test.Quarantine != nil {
+				testSpan.Attributes = append(testSpan.Attributes, boolAttribute("go.test.quarantined", true))
+			}
+			if len(test.Attempts) > 0 {
+				testSpan.Attributes = append(testSpan.Attributes, intAttribute("go.test.attempts", int64(len(test.Attempts))))
+			}
+			for idx, attempt := range test.Attempts {
+				eventTime := attempt.Started
+				if eventTime.IsZero() {
+					eventTime = end
+				}
+				testSpan.Events = append(testSpan.Events, otlpEvent{
+					TimeUnixNano: unixNano(eventTime),
+					Name:         "rerun",
+					Attributes: []otlpAttribute{
+						intAttribute("go.test.attempt", int64(idx+1)),
+						stringAttribute("test.case.result.status", attempt.TestResult.String()),
+						intAttribute("go.test.duration_ns", int64(attempt.Duration)),
+					},
+				})
+			}
+			testSpan.Status = otlpStatusOf(test.TestResult)
+			spanOfTest[test.Name] = testSpan.SpanID
+			names[test.Name] = true
+			testSpans = append(testSpans, testSpan)
+		}
+		// Subtests can be listed before their parent, so parents are looked up once all spans exist
+		for idx := range testSpans {
+			if parent, ok := spanOfTest[report.ParentTest(names, testSpans[idx].Name)]; ok {
+				testSpans[idx].ParentSpanID = parent
+			}
+		}
+		spans = append(spans, testSpans...)
+	}
+
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{stringAttribute("service.name", "go-testreport")}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "github.com/becheran/go-testreport"}, Spans: spans}},
+	}}}
+}
+
+// OTLP writes the result as OpenTelemetry trace in the OTLP JSON encoding. The run, packages, tests
+// and subtests are nested spans.
+func OTLP(result report.Result, out io.Writer, _ Options) error {
+	return json.NewEncoder(out).Encode(otlp(result))
+}
+
+// SendOTLP posts the result as OpenTelemetry trace to the OTLP/HTTP endpoint such as
+// http://localhost:4318/v1/traces.
+func SendOTLP(client *http.Client, endpoint string, headers map[string]string, result report.Result) error {
+	body := bytes.NewBuffer(nil)
+	if err := OTLP(result, body, Options{}); err != nil {
+		return err
+	}
+	return post(client, endpoint, headers, body)
+}
@@ -0,0 +1,165 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Start        string `json:"startTimeUnixNano"`
+	End          string `json:"endTimeUnixNano"`
+	Attributes   []struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	} `json:"attributes"`
+	Events []struct {
+		Time string `json:"timeUnixNano"`
+		Name string `json:"name"`
+	} `json:"events"`
+	Status struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+func (s otlpSpan) attribute(key string) any {
+	for _, attr := range s.Attributes {
+		if attr.Key == key {
+			for _, value := range attr.Value {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+func parseOTLP(t *testing.T, data []byte) map[string]otlpSpan {
+	var traces struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.Nil(t, json.Unmarshal(data, &traces))
+	require.Len(t, traces.ResourceSpans, 1)
+	require.Len(t, traces.ResourceSpans[0].ScopeSpans, 1)
+	spans := make(map[string]otlpSpan)
+	for _, span := range traces.ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[span.Name] = span
+	}
+	return spans
+}
+
+func TestOTLP(t *testing.T) {
+	result, err := report.ParseTestJson(strings.NewReader(parallelJson))
+	require.Nil(t, err)
+	rerun := time.Date(2024, 1, 1, 10, 0, 5, 0, time.UTC)
+	result.PackageResult[0].Tests[0].Attempts = []report.Attempt{{TestResult: report.FTSFail, Started: rerun}, {TestResult: report.FTSPass}}
+	result.UpdateTotals()
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.OTLP(result, buff, format.Options{}))
+
+	spans := parseOTLP(t, buff.Bytes())
+	require.Len(t, spans, 5)
+	run, pack := spans["go test"], spans["example.com/a"]
+	testA, sub, testB := spans["TestA"], spans["TestA/sub"], spans["TestB"]
+	assert.Empty(t, run.ParentSpanID)
+	assert.Equal(t, run.SpanID, pack.ParentSpanID)
+	assert.Equal(t, pack.SpanID, testA.ParentSpanID)
+	assert.Equal(t, pack.SpanID, testB.ParentSpanID)
+	assert.Equal(t, testA.SpanID, sub.ParentSpanID)
+	for _, span := range spans {
+		assert.Equal(t, run.TraceID, span.TraceID)
+		assert.Len(t, span.TraceID, 32)
+		assert.Len(t, span.SpanID, 16)
+	}
+
+	assert.Equal(t, "1704103200000000000", pack.Start)
+	assert.Equal(t, "1704103203000000000", pack.End)
+	assert.Equal(t, 2, pack.Status.Code)
+	assert.Equal(t, 1, testB.Status.Code)
+	assert.Equal(t, "example.com/a", testA.attribute("test.suite.name"))
+	assert.Equal(t, "fail", testA.attribute("test.case.result.status"))
+	assert.Equal(t, "flaky", testA.attribute("go.test.classification"))
+	assert.Equal(t, "2", testA.attribute("go.test.attempts"))
+	require.Len(t, testA.Events, 2)
+	assert.Equal(t, strconv.FormatInt(rerun.UnixNano(), 10), testA.Events[0].Time)
+	assert.Equal(t, testA.End, testA.Events[1].Time, "reruns without start time are at the end of the test")
+	assert.Equal(t, "failed", sub.attribute("go.test.classification"))
+	assert.Equal(t, "1", run.attribute("go.test.flaky"))
+	assert.Equal(t, 2, run.Status.Code, "the subtest failed persistently")
+
+	buff2 := bytes.NewBuffer(nil)
+	require.Nil(t, format.OTLP(result, buff2, format.Options{}))
+	assert.Equal(t, buff.String(), buff2.String(), "exports are deterministic")
+}
+
+func TestOTLP_MissingTimes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	result := report.Result{PackageResult: []report.PackageResult{{Name: "example.com/a", Started: start, Tests: []report.TestResult{
+		{Name: "TestA/example.com/sub", TestResult: report.FTSFail, Started: start.Add(time.Second), Ended: start.Add(2 * time.Second)},
+		{Name: "TestA", TestResult: report.FTSIncomplete, Started: start.Add(time.Second)},
+		{Name: "TestB", TestResult: report.FTSPass},
+	}}}}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.OTLP(result, buff, format.Options{}))
+
+	spans := parseOTLP(t, buff.Bytes())
+	pack, testA, sub, testB := spans["example.com/a"], spans["TestA"], spans["TestA/example.com/sub"], spans["TestB"]
+	assert.Equal(t, "1704103200000000000", pack.Start)
+	assert.Equal(t, "1704103200000000000", pack.End)
+	assert.Equal(t, "1704103201000000000", testA.Start)
+	assert.Equal(t, "1704103201000000000", testA.End)
+	assert.Equal(t, "1704103200000000000", testB.Start)
+	assert.Equal(t, "1704103200000000000", testB.End)
+	assert.Equal(t, testA.SpanID, sub.ParentSpanID, "subtests listed before their parent")
+	assert.Equal(t, pack.SpanID, testA.ParentSpanID)
+}
+
+func TestSendOTLP(t *testing.T) {
+	result, err := report.ParseTestJson(strings.NewReader(parallelJson))
+	require.Nil(t, err)
+	var body []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	err = format.SendOTLP(collector.Client(), collector.URL+"/v1/traces", map[string]string{"Authorization": "secret"}, result)
+	require.Nil(t, err)
+	assert.Len(t, parseOTLP(t, body), 5)
+}
+
+func TestSendOTLP_Error(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid trace", http.StatusBadRequest)
+	}))
+	defer collector.Close()
+
+	err := format.SendOTLP(collector.Client(), collector.URL, nil, report.Result{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid trace")
+}
@@ -0,0 +1,162 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Static Analysis Results Interchange Format 2.1.0
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+var sarifRuleDescriptions = map[failureKind]string{
+	kindAssertion:  "A test assertion failed",
+	kindPanic:      "A test panicked",
+	kindTimeout:    "A test did not finish before the timeout",
+	kindDataRace:   "The race detector found a data race",
+	kindBuildError: "A package failed to build",
+}
+
+func sarifRuleName(kind failureKind) string {
+	name := strings.Builder{}
+	for _, part := range strings.Split(string(kind), "-") {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return name.String()
+}
+
+// SARIF writes every failed test and package which failed without failed tests as result of the Static
+// Analysis Results Interchange Format 2.1.0. Every kind of failure has its own rule. Failures of flaky
+// tests are notes and failures of quarantined tests are warnings.
+func SARIF(result report.Result, out io.Writer, _ Options) error {
+	driver := sarifDriver{Name: "go-testreport", Version: toolVersion(), InformationURI: "https://github.com/becheran/go-testreport"}
+	ruleIndex := make(map[failureKind]int)
+	for idx, kind := range failureKinds {
+		ruleIndex[kind] = idx
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   "go-test/" + string(kind),
+			Name:                 sarifRuleName(kind),
+			ShortDescription:     sarifMessage{Text: sarifRuleDescriptions[kind]},
+			DefaultConfiguration: sarifConfiguration{Level: "error"},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, f := range failures(result) {
+		text := f.message
+		if output := strings.TrimSpace(f.output); output != "" {
+			text += "\n\n" + output
+		}
+		location := sarifLocation{LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: string(f.pack.Name), Kind: "package"}}}
+		properties := map[string]any{"package": string(f.pack.Name)}
+		if f.test != nil {
+			text = fmt.Sprintf("%s: %s", f.test.Name, text)
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: string(f.pack.Name) + "." + f.test.Name, Kind: "function"}}
+			properties["test"] = f.test.Name
+			properties["status"] = f.test.Classification()
+			if len(f.test.Attempts) > 0 {
+				attempts := make([]string, 0, len(f.test.Attempts))
+				for _, attempt := range f.test.Attempts {
+					attempts = append(attempts, attempt.TestResult.String())
+				}
+				properties["attempts"] = attempts
+			}
+		}
+		if f.file != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: f.file, URIBaseID: "%SRCROOT%"}}
+			if f.line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: f.line}
+			}
+		}
+		results = append(results, sarifResult{
+			RuleID:              "go-test/" + string(f.kind),
+			RuleIndex:           ruleIndex[f.kind],
+			Level:               f.severity,
+			Message:             sarifMessage{Text: text},
+			Locations:           []sarifLocation{location},
+			PartialFingerprints: map[string]string{"goTestFailure/v1": f.fingerprint()},
+			Properties:          properties,
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
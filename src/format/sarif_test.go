@@ -0,0 +1,181 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex int    `json:"ruleIndex"`
+	Level     string `json:"level"`
+	Message   struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []struct {
+		PhysicalLocation *struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *struct {
+				StartLine int `json:"startLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+		LogicalLocations []struct {
+			FullyQualifiedName string `json:"fullyQualifiedName"`
+		} `json:"logicalLocations"`
+	} `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties"`
+}
+
+type sarifLog struct {
+	Version string `json:"version"`
+	Runs    []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID string `json:"id"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []sarifResult `json:"results"`
+	} `json:"runs"`
+}
+
+func output(lines ...string) (res []report.OutputLine) {
+	for _, line := range lines {
+		res = append(res, report.OutputLine{Text: line + "\n"})
+	}
+	return res
+}
+
+func TestSARIF(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestParent", TestResult: report.FTSFail},
+			{Name: "TestParent/assert", TestResult: report.FTSFail,
+				Assertions: []report.Assertion{{Trace: "a_test.go:10", Message: "Not equal"}},
+				Output:     output("=== RUN   TestParent/assert", "    a_test.go:10: Not equal")},
+			{Name: "TestPanic", TestResult: report.FTSFail, Output: output("--- FAIL: TestPanic", "panic: boom [recovered]")},
+			{Name: "TestRace", TestResult: report.FTSFail, Output: output("==================", "WARNING: DATA RACE")},
+			{Name: "TestFlaky", TestResult: report.FTSFail, Output: output("    a_test.go:20: flaky"),
+				Attempts: []report.Attempt{{TestResult: report.FTSPass}}},
+			{Name: "TestQuarantined", TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+			{Name: "TestTimeout", TestResult: report.FTSIncomplete},
+			{Name: "TestPass", TestResult: report.FTSPass},
+		}, Output: output("panic: test timed out after 1s")},
+		{Name: "example.com/bad", PackageResult: report.FTSFail,
+			Output: output("# example.com/bad [example.com/bad.test]", "bad/bad.go:3:23: undefined: x")},
+		{Name: "example.com/ok", PackageResult: report.FTSPass},
+	}}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.SARIF(result, buff, format.Options{}))
+
+	var log sarifLog
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	driver := log.Runs[0].Tool.Driver
+	assert.Equal(t, "go-testreport", driver.Name)
+	require.Len(t, driver.Rules, 5)
+
+	results := log.Runs[0].Results
+	suite := []struct {
+		rule, level, message, file string
+		line                       int
+	}{
+		{"go-test/assertion", "error", "TestParent/assert: Not equal", "a_test.go", 10},
+		{"go-test/panic", "error", "TestPanic: panic: boom [recovered]", "", 0},
+		{"go-test/data-race", "error", "TestRace: data race detected", "", 0},
+		{"go-test/assertion", "note", "TestFlaky: a_test.go:20: flaky", "a_test.go", 20},
+		{"go-test/assertion", "warning", "TestQuarantined: test failed", "", 0},
+		{"go-test/timeout", "error", "TestTimeout: panic: test timed out after 1s", "", 0},
+		{"go-test/build-error", "error", "bad/bad.go:3:23: undefined: x", "bad/bad.go", 3},
+	}
+	require.Len(t, results, len(suite))
+	for idx, s := range suite {
+		t.Run(s.message, func(t *testing.T) {
+			res := results[idx]
+			assert.Equal(t, s.rule, res.RuleID)
+			assert.Equal(t, s.rule, driver.Rules[res.RuleIndex].ID)
+			assert.Equal(t, s.level, res.Level)
+			assert.Contains(t, res.Message.Text, s.message)
+			assert.Len(t, res.PartialFingerprints["goTestFailure/v1"], 32)
+			require.Len(t, res.Locations, 1)
+			if s.file == "" {
+				assert.Nil(t, res.Locations[0].PhysicalLocation)
+			} else {
+				require.NotNil(t, res.Locations[0].PhysicalLocation)
+				assert.Equal(t, s.file, res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+				assert.Equal(t, s.line, res.Locations[0].PhysicalLocation.Region.StartLine)
+			}
+		})
+	}
+	assert.Equal(t, "example.com/a.TestParent/assert", results[0].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, "flaky", results[3].Properties["status"])
+	assert.Equal(t, []any{"pass"}, results[3].Properties["attempts"])
+	assert.Equal(t, "example.com/bad", results[6].Properties["package"])
+}
+
+func TestSARIF_StableFingerprints(t *testing.T) {
+	fingerprint := func(duration string) string {
+		result := report.Result{PackageResult: []report.PackageResult{{Name: "example.com/a", PackageResult: report.FTSFail,
+			Tests: []report.TestResult{{Name: "TestFail", TestResult: report.FTSFail, Output: output("    a_test.go:10: took " + duration)}}}}}
+		buff := bytes.NewBuffer(nil)
+		require.Nil(t, format.SARIF(result, buff, format.Options{}))
+		var log sarifLog
+		require.Nil(t, json.Unmarshal(buff.Bytes(), &log))
+		return log.Runs[0].Results[0].PartialFingerprints["goTestFailure/v1"]
+	}
+
+	assert.Equal(t, fingerprint("1s"), fingerprint("2s"))
+}
+
+// errorTraceResult has a failed test whose error trace is the absolute file, as logged by testify.
+func errorTraceResult(file string) report.Result {
+	return report.Result{Tests: 1, Failed: 1, PackageResult: []report.PackageResult{{Name: "example.com/a", Dir: "a", PackageResult: report.FTSFail,
+		Tests: []report.TestResult{{Name: "TestA", TestResult: report.FTSFail, Assertions: []report.Assertion{{Trace: file + ":12", Message: "Not equal"}}}}}}}
+}
+
+func TestSARIF_AbsoluteErrorTrace(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	require.Nil(t, err)
+	for _, file := range []string{filepath.Join(wd, "a", "x_test.go"), filepath.Join(filepath.Dir(wd), "x_test.go")} {
+		t.Run(file, func(t *testing.T) {
+			buff := bytes.NewBuffer(nil)
+
+			require.Nil(t, format.SARIF(errorTraceResult(file), buff, format.Options{}))
+
+			var log sarifLog
+			require.Nil(t, json.Unmarshal(buff.Bytes(), &log))
+			results := log.Runs[0].Results
+			require.Len(t, results, 1)
+			require.Len(t, results[0].Locations, 1)
+			if physical := results[0].Locations[0].PhysicalLocation; strings.HasPrefix(file, wd) {
+				require.NotNil(t, physical)
+				assert.Equal(t, "a/x_test.go", physical.ArtifactLocation.URI)
+			} else {
+				assert.Nil(t, physical, "files outside of the working directory have no physical location")
+			}
+		})
+	}
+}
+
+func TestSARIF_NoFailures(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.SARIF(report.Result{}, buff, format.Options{}))
+
+	assert.Contains(t, buff.String(), `"results": []`)
+}
@@ -0,0 +1,109 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Slack Block Kit message
+// https://api.slack.com/reference/block-kit/blocks
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string     `json:"type"`
+	Text     *slackText `json:"text,omitempty"`
+	Elements []any      `json:"elements,omitempty"` // text objects of context blocks or buttons of actions
+}
+
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+type slackButton struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+// Limits of the text of blocks
+const (
+	slackMaxHeader  = 150
+	slackMaxSection = 3000
+)
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes the text and truncates it to at most max characters without cutting escape sequences.
+func slackEscape(text string, max int) string {
+	escaped := slackEscaper.Replace(text)
+	if utf8.RuneCountInString(escaped) <= max {
+		return escaped
+	}
+	var b strings.Builder
+	length := 0
+	for _, r := range text {
+		e := slackEscaper.Replace(string(r))
+		if length+utf8.RuneCountInString(e) > max-1 {
+			break
+		}
+		b.WriteString(e)
+		length += utf8.RuneCountInString(e)
+	}
+	return b.String() + "…"
+}
+
+// slackFallback returns the text of notifications. Like the summary, flaky and quarantined tests are not failures.
+func slackFallback(title string, result report.Result) string {
+	if failed := result.PersistentFailures(); failed > 0 {
+		return fmt.Sprintf("%s: %d of %d tests failed", title, failed, result.Tests)
+	}
+	for _, pack := range result.PackageResult {
+		if pack.Failed() {
+			return fmt.Sprintf("%s failed", title)
+		}
+	}
+	return fmt.Sprintf("%s passed", title)
+}
+
+// Slack writes the result as Slack Block Kit message with the summary, the first failed tests and a button
+// which links to the RunURL variable. Flaky and quarantined tests are only counted.
+func Slack(result report.Result, out io.Writer, _ Options) error {
+	title := chatTitle(result)
+	msg := slackMessage{Text: slackEscaper.Replace(slackFallback(title, result))}
+	msg.Blocks = append(msg.Blocks,
+		slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(title, slackMaxHeader), Emoji: true}},
+		slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackEscaper.Replace(chatSummary(result))}},
+	)
+
+	failed, more := chatFailures(result, maxChatFailures)
+	if len(failed) > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "divider"})
+	}
+	for _, f := range failed {
+		name, message := chatFailure(f)
+		// Code blocks end at the next triple backtick
+		message = strings.ReplaceAll(message, "```", "'''")
+		// The message is truncated inside of the code block, so that the block is always closed
+		text := fmt.Sprintf("❌ *%s* %s\n```", slackEscape(name, slackMaxHeader), slackEscape(string(f.pack.Name), slackMaxHeader))
+		text += slackEscape(message, slackMaxSection-utf8.RuneCountInString(text)-len("```")) + "```"
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}})
+	}
+	if more > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []any{slackText{Type: "mrkdwn", Text: fmt.Sprintf("and %d more failures", more)}}})
+	}
+	if url := result.Vars["RunURL"]; url != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "actions", Elements: []any{slackButton{Type: "button", Text: slackText{Type: "plain_text", Text: "View run"}, URL: url}}})
+	}
+
+	return json.NewEncoder(out).Encode(msg)
+}
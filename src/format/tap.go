@@ -0,0 +1,118 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+var tapEscaper = strings.NewReplacer(`\`, `\\`, "#", `\#`)
+
+// tapWriter writes indented TAP lines. The first write error is kept.
+type tapWriter struct {
+	out *bufio.Writer
+	err error
+}
+
+func (w *tapWriter) line(indent int, format string, a ...any) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintf(w.out, strings.Repeat("    ", indent)+format+"\n", a...)
+	}
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64)
+}
+
+// tapDirective returns the SKIP or TODO directive of the test. Failures which do not cause a non
+// zero exit code are marked with TODO so that TAP consumers do not count them.
+func tapDirective(test report.TestResult) string {
+	switch {
+	case test.TestResult == report.FTPSSkip:
+		return " # SKIP"
+	case test.Flaky():
+		return fmt.Sprintf(" # TODO flaky, passed on rerun %d", len(test.Attempts))
+	case test.TestResult == report.FTSFail && test.Quarantine != nil:
+		return " # TODO quarantined"
+	default:
+		return ""
+	}
+}
+
+// diagnostics writes the YAML block with the duration, reruns and the output of failed tests.
+func (w *tapWriter) diagnostics(indent int, test report.TestResult) {
+	w.line(indent, "  ---")
+	w.line(indent, "  duration_ms: %s", milliseconds(test.Duration))
+	w.line(indent, "  status: %s", test.Classification())
+	if len(test.Attempts) > 0 {
+		w.line(indent, "  attempts:")
+		for _, attempt := range test.Attempts {
+			w.line(indent, "    - status: %s", attempt.TestResult)
+			w.line(indent, "      duration_ms: %s", milliseconds(attempt.Duration))
+		}
+	}
+	if test.TestResult == report.FTSFail || test.TestResult == report.FTSIncomplete {
+		if len(test.Assertions) > 0 {
+			w.line(indent, "  message: %s", strconv.Quote(test.Assertions[0].Message))
+			if trace := test.Assertions[0].Trace; trace != "" {
+				w.line(indent, "  at: %s", strconv.Quote(trace))
+			}
+		}
+		output := strings.Builder{}
+		for _, line := range test.Output {
+			output.WriteString(line.Text)
+		}
+		if text := strings.TrimRight(output.String(), "\n"); text != "" {
+			// The indentation indicator is required if the first line starts with a space
+			indicator := ""
+			if strings.HasPrefix(text, " ") {
+				indicator = "2"
+			}
+			w.line(indent, "  output: |%s", indicator)
+			for _, line := range strings.Split(text, "\n") {
+				w.line(indent, "    %s", strings.TrimRight(line, "\r"))
+			}
+		}
+	}
+	w.line(indent, "  ...")
+}
+
+// TAP writes the result in the Test Anything Protocol version 14. Every package is a subtest with
+// an ok or not ok line for each test. The YAML diagnostics contain the duration, the reruns and the
+// output of failed tests.
+func TAP(result report.Result, out io.Writer, _ Options) error {
+	w := &tapWriter{out: bufio.NewWriter(out)}
+	w.line(0, "TAP version 14")
+	w.line(0, "1..%d", len(result.PackageResult))
+	for pIdx, pack := range result.PackageResult {
+		w.line(0, "# Subtest: %s", pack.Name)
+		w.line(1, "1..%d", len(pack.Tests))
+		for tIdx, test := range pack.Tests {
+			status := "ok"
+			if test.TestResult == report.FTSFail || test.TestResult == report.FTSIncomplete {
+				status = "not ok"
+			}
+			w.line(1, "%s %d - %s%s", status, tIdx+1, tapEscaper.Replace(test.Name), tapDirective(test))
+			if test.TestResult != report.FTPSSkip {
+				w.diagnostics(1, test)
+			}
+		}
+		status := "ok"
+		if pack.Failed() {
+			status = "not ok"
+		}
+		w.line(0, "%s %d - %s", status, pIdx+1, tapEscaper.Replace(string(pack.Name)))
+		w.line(0, "  ---")
+		w.line(0, "  duration_ms: %s", milliseconds(pack.Duration))
+		w.line(0, "  ...")
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return w.out.Flush()
+}
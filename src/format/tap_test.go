@@ -0,0 +1,75 @@
+package format_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTAP(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", Duration: 1500 * time.Millisecond, PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestFail/#00", Duration: 1200 * time.Microsecond, TestResult: report.FTSFail,
+				Assertions: []report.Assertion{{Trace: "a_test.go:10", Message: "Not equal"}},
+				Output:     []report.OutputLine{{Text: "    a_test.go:10: Not equal\n"}, {Text: "--- FAIL: TestFail/#00\n"}}},
+			{Name: "TestFlaky", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass, Duration: time.Millisecond}}},
+			{Name: "TestQuarantined", TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+			{Name: "TestSkip", TestResult: report.FTPSSkip},
+			{Name: "TestPass", Duration: 2 * time.Millisecond, TestResult: report.FTSPass},
+		}},
+		{Name: "example.com/b", PackageResult: report.FTSPass},
+	}}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.TAP(result, buff, format.Options{}))
+
+	assert.Equal(t, `TAP version 14
+1..2
+# Subtest: example.com/a
+    1..5
+    not ok 1 - TestFail/\#00
+      ---
+      duration_ms: 1.2
+      status: failed
+      message: "Not equal"
+      at: "a_test.go:10"
+      output: |2
+            a_test.go:10: Not equal
+        --- FAIL: TestFail/#00
+      ...
+    not ok 2 - TestFlaky # TODO flaky, passed on rerun 1
+      ---
+      duration_ms: 0
+      status: flaky
+      attempts:
+        - status: pass
+          duration_ms: 1
+      ...
+    not ok 3 - TestQuarantined # TODO quarantined
+      ---
+      duration_ms: 0
+      status: failed
+      ...
+    ok 4 - TestSkip # SKIP
+    ok 5 - TestPass
+      ---
+      duration_ms: 2
+      status: passed
+      ...
+not ok 1 - example.com/a
+  ---
+  duration_ms: 1500
+  ...
+# Subtest: example.com/b
+    1..0
+ok 2 - example.com/b
+  ---
+  duration_ms: 0
+  ...
+`, buff.String())
+}
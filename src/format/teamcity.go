@@ -0,0 +1,308 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Service messages which are interpreted by TeamCity
+// https://www.jetbrains.com/help/teamcity/service-messages.html
+var teamCityEscaper = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]",
+	"\u0085", "|x", "\u2028", "|l", "\u2029", "|p")
+
+// teamCityWriter writes service messages. The first write error is kept.
+type teamCityWriter struct {
+	out io.Writer
+	err error
+}
+
+// message writes a service message with attributes given as alternating names and values.
+// Attributes with empty values are omitted.
+func (w *teamCityWriter) message(name string, attributes ...string) {
+	if w.err != nil {
+		return
+	}
+	msg := strings.Builder{}
+	msg.WriteString("##teamcity[" + name)
+	for idx := 0; idx+1 < len(attributes); idx += 2 {
+		if attributes[idx+1] != "" {
+			msg.WriteString(" " + attributes[idx] + "='" + teamCityEscaper.Replace(attributes[idx+1]) + "'")
+		}
+	}
+	msg.WriteString("]\n")
+	_, w.err = io.WriteString(w.out, msg.String())
+}
+
+func teamCityTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02T15:04:05.000-0700")
+}
+
+func (w *teamCityWriter) suiteStarted(pack string, t time.Time) {
+	w.message("testSuiteStarted", "name", pack, "flowId", pack, "timestamp", teamCityTimestamp(t))
+}
+
+func (w *teamCityWriter) suiteFinished(pack string, t time.Time) {
+	w.message("testSuiteFinished", "name", pack, "flowId", pack, "timestamp", teamCityTimestamp(t))
+}
+
+func (w *teamCityWriter) testStarted(flowID, name string, t time.Time) {
+	w.message("testStarted", "name", name, "captureStandardOutput", "false", "flowId", flowID, "timestamp", teamCityTimestamp(t))
+}
+
+// testFinished writes the result and the output of a test run. Failed tests get the output as details.
+func (w *teamCityWriter) testFinished(flowID, name string, status report.FinalTestStatus, duration time.Duration, output []report.OutputLine, message string, t time.Time) {
+	text := joinOutput(output)
+	switch status {
+	case report.FTPSSkip:
+		w.message("testIgnored", "name", name, "message", "skipped", "flowId", flowID)
+	case report.FTSFail, report.FTSIncomplete:
+		w.message("testFailed", "name", name, "message", message, "details", text, "flowId", flowID)
+	case report.FTSPass:
+		if text != "" {
+			w.message("testStdOut", "name", name, "out", text, "flowId", flowID)
+		}
+	}
+	w.message("testFinished", "name", name, "duration", strconv.FormatInt(duration.Milliseconds(), 10), "flowId", flowID, "timestamp", teamCityTimestamp(t))
+}
+
+// attempts writes every rerun of the test as another run of the test, which TeamCity uses to detect
+// flaky tests.
+func (w *teamCityWriter) attempts(flowID string, test report.TestResult) {
+	for idx, attempt := range test.Attempts {
+		message := fmt.Sprintf("failed on rerun %d", idx+1)
+		if attempt.TestResult == report.FTSIncomplete {
+			message = fmt.Sprintf("did not finish on rerun %d", idx+1)
+		}
+		w.testStarted(flowID, test.Name, time.Time{})
+		w.testFinished(flowID, test.Name, attempt.TestResult, attempt.Duration, attempt.Output, message, time.Time{})
+	}
+}
+
+// buildProblems reports failures of packages without failed tests, such as build errors, as build problems.
+func (w *teamCityWriter) buildProblems(result report.Result) {
+	for _, f := range failures(result) {
+		if f.test == nil {
+			w.message("buildProblem", "description", f.message, "identity", f.fingerprint())
+		}
+	}
+}
+
+// testMessage returns the message of the failed test.
+func testMessage(test report.TestResult) string {
+	message, _ := failureMessage(test)
+	if message == "" && test.TestResult == report.FTSIncomplete {
+		message = "test did not finish"
+	}
+	if test.Quarantine != nil {
+		message = strings.TrimSpace(message + " (quarantined)")
+	}
+	return message
+}
+
+// TeamCity writes the result as TeamCity service messages. Every package is a test suite and every rerun is
+// reported as another run of the test. Packages which failed without failed tests are build problems.
+func TeamCity(result report.Result, out io.Writer, _ Options) error {
+	buff := bufio.NewWriter(out)
+	w := &teamCityWriter{out: buff}
+	for _, pack := range result.PackageResult {
+		name := string(pack.Name)
+		w.suiteStarted(name, pack.Started)
+		for _, test := range pack.Tests {
+			w.testStarted(name, test.Name, test.Started)
+			w.testFinished(name, test.Name, test.TestResult, test.Duration, test.Output, testMessage(test), test.Ended)
+			w.attempts(name, test)
+		}
+		w.buildProblems(report.Result{PackageResult: []report.PackageResult{pack}})
+		w.suiteFinished(name, pack.Ended)
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return buff.Flush()
+}
+
+// streamPackage is a package of which the tests are streamed.
+type streamPackage struct {
+	result      report.PackageResult
+	tests       map[string]*report.TestResult
+	running     []string // running tests in the order in which they were started
+	failedTests int
+}
+
+// TeamCityStream writes TeamCity service messages while the output of go test -json is written to it.
+// Tests are reported as soon as they finish.
+type TeamCityStream struct {
+	w        *teamCityWriter
+	line     []byte
+	packages map[string]*streamPackage
+	build    map[string][]report.OutputLine
+}
+
+// NewTeamCityStream returns a stream which writes the service messages to out.
+func NewTeamCityStream(out io.Writer) *TeamCityStream {
+	return &TeamCityStream{
+		w:        &teamCityWriter{out: out},
+		packages: make(map[string]*streamPackage),
+		build:    make(map[string][]report.OutputLine),
+	}
+}
+
+// Write converts all complete lines of test events. Errors are returned by Close.
+func (s *TeamCityStream) Write(p []byte) (int, error) {
+	s.line = append(s.line, p...)
+	for {
+		idx := bytes.IndexByte(s.line, '\n')
+		if idx < 0 {
+			break
+		}
+		s.event(s.line[:idx])
+		s.line = s.line[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Close converts the last line and finishes all packages which are still running. Their running tests are
+// reported as failed.
+func (s *TeamCityStream) Close() error {
+	if len(s.line) > 0 {
+		s.event(s.line)
+		s.line = nil
+	}
+	names := make([]string, 0, len(s.packages))
+	for name := range s.packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.finishPackage(name, report.FTSIncomplete, time.Time{})
+	}
+	return s.w.err
+}
+
+// Reruns writes the reruns of the tests after the stream was closed.
+func (s *TeamCityStream) Reruns(result report.Result) error {
+	for _, pack := range result.PackageResult {
+		started := false
+		for _, test := range pack.Tests {
+			if len(test.Attempts) == 0 {
+				continue
+			}
+			if !started {
+				s.w.suiteStarted(string(pack.Name), time.Time{})
+				started = true
+			}
+			s.w.attempts(string(pack.Name), test)
+		}
+		if started {
+			s.w.suiteFinished(string(pack.Name), time.Time{})
+		}
+	}
+	return s.w.err
+}
+
+func (s *TeamCityStream) event(line []byte) {
+	// Ignore Byte Order Mark (BOM)
+	line = bytes.TrimPrefix(line, []byte{239, 187, 191})
+	var evt report.TestEvent
+	if err := json.Unmarshal(line, &evt); err != nil {
+		// Ignore parse errors
+		return
+	}
+	if evt.Action == report.TABuildOutput || evt.Action == report.TABuildFail {
+		if evt.Output != "" {
+			importPath := report.BuildPackage(evt.ImportPath)
+			s.build[importPath] = append(s.build[importPath], report.OutputLine{Time: evt.Time, Text: evt.Output})
+		}
+		return
+	}
+
+	pack, ok := s.packages[evt.Package]
+	if !ok {
+		pack = &streamPackage{result: report.PackageResult{Name: report.PackageName(evt.Package), Started: evt.Time}, tests: make(map[string]*report.TestResult)}
+		s.packages[evt.Package] = pack
+		s.w.suiteStarted(evt.Package, evt.Time)
+	}
+	status := report.FinalTestStatusFromAction(evt.Action)
+	if evt.Test == "" {
+		if evt.Action == report.TAOutput {
+			pack.result.Output = append(pack.result.Output, report.OutputLine{Time: evt.Time, Text: evt.Output})
+		}
+		if status != nil {
+			s.finishPackage(evt.Package, *status, evt.Time)
+		}
+		return
+	}
+
+	flowID := evt.Package + " " + evt.Test
+	test, ok := pack.tests[evt.Test]
+	if !ok {
+		test = &report.TestResult{Name: evt.Test, TestResult: report.FTSIncomplete, Started: evt.Time}
+		pack.tests[evt.Test] = test
+		pack.running = append(pack.running, evt.Test)
+		s.w.message("flowStarted", "flowId", flowID, "parent", evt.Package)
+		s.w.testStarted(flowID, evt.Test, evt.Time)
+	}
+	if evt.Action == report.TAOutput {
+		test.Output = append(test.Output, report.OutputLine{Time: evt.Time, Text: evt.Output})
+	}
+	if status != nil {
+		test.TestResult = *status
+		test.Duration = time.Duration(float64(time.Second) * evt.ElapsedSec)
+		test.Ended = evt.Time
+		s.finishTest(pack, test)
+	}
+}
+
+func (s *TeamCityStream) finishTest(pack *streamPackage, test *report.TestResult) {
+	if test.TestResult == report.FTSFail || test.TestResult == report.FTSIncomplete {
+		pack.failedTests++
+	}
+	flowID := string(pack.result.Name) + " " + test.Name
+	s.w.testFinished(flowID, test.Name, test.TestResult, test.Duration, test.Output, testMessage(*test), test.Ended)
+	s.w.message("flowFinished", "flowId", flowID)
+	for idx, name := range pack.running {
+		if name == test.Name {
+			pack.running = append(pack.running[:idx], pack.running[idx+1:]...)
+			break
+		}
+	}
+	delete(pack.tests, test.Name)
+}
+
+// finishPackage fails all tests which are still running and reports a build problem if the package
+// failed without failed tests.
+func (s *TeamCityStream) finishPackage(name string, status report.FinalTestStatus, t time.Time) {
+	pack := s.packages[name]
+	output := joinOutput(pack.result.Output)
+	for len(pack.running) > 0 {
+		test := pack.tests[pack.running[0]]
+		if !t.IsZero() && !test.Started.IsZero() {
+			test.Duration = t.Sub(test.Started)
+		}
+		test.Ended = t
+		if timeout := firstLine(output, "panic: test timed out"); timeout != "" {
+			test.Output = append(test.Output, report.OutputLine{Text: timeout + "\n"})
+		}
+		s.finishTest(pack, test)
+	}
+	if status == report.FTSFail && pack.failedTests == 0 {
+		pack.result.PackageResult = status
+		pack.result.Output = append(s.build[name], pack.result.Output...)
+		s.w.buildProblems(report.Result{PackageResult: []report.PackageResult{pack.result}})
+	}
+	s.w.suiteFinished(name, t)
+	delete(s.packages, name)
+	delete(s.build, name)
+}
@@ -0,0 +1,130 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/format"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamCity(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestFail", Duration: 1500 * time.Microsecond, TestResult: report.FTSFail,
+				Started: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+				Output:  output("    a_test.go:10: it's [1|2]")},
+			{Name: "TestFlaky", TestResult: report.FTSFail, Output: output("    a_test.go:20: flaky"),
+				Attempts: []report.Attempt{{TestResult: report.FTSPass, Duration: 2 * time.Millisecond}}},
+			{Name: "TestSkip", TestResult: report.FTPSSkip},
+		}},
+		{Name: "example.com/bad", PackageResult: report.FTSFail, Output: output("bad/bad.go:3:23: undefined: x")},
+	}}
+	buff := bytes.NewBuffer(nil)
+
+	require.Nil(t, format.TeamCity(result, buff, format.Options{}))
+
+	assert.Equal(t, `##teamcity[testSuiteStarted name='example.com/a' flowId='example.com/a']
+##teamcity[testStarted name='TestFail' captureStandardOutput='false' flowId='example.com/a' timestamp='2024-01-01T10:00:00.000+0000']
+##teamcity[testFailed name='TestFail' message='a_test.go:10: it|'s |[1||2|]' details='    a_test.go:10: it|'s |[1||2|]|n' flowId='example.com/a']
+##teamcity[testFinished name='TestFail' duration='1' flowId='example.com/a']
+##teamcity[testStarted name='TestFlaky' captureStandardOutput='false' flowId='example.com/a']
+##teamcity[testFailed name='TestFlaky' message='a_test.go:20: flaky' details='    a_test.go:20: flaky|n' flowId='example.com/a']
+##teamcity[testFinished name='TestFlaky' duration='0' flowId='example.com/a']
+##teamcity[testStarted name='TestFlaky' captureStandardOutput='false' flowId='example.com/a']
+##teamcity[testFinished name='TestFlaky' duration='2' flowId='example.com/a']
+##teamcity[testStarted name='TestSkip' captureStandardOutput='false' flowId='example.com/a']
+##teamcity[testIgnored name='TestSkip' message='skipped' flowId='example.com/a']
+##teamcity[testFinished name='TestSkip' duration='0' flowId='example.com/a']
+##teamcity[testSuiteFinished name='example.com/a' flowId='example.com/a']
+##teamcity[testSuiteStarted name='example.com/bad' flowId='example.com/bad']
+##teamcity[buildProblem description='bad/bad.go:3:23: undefined: x' identity='`+fingerprintOf(t, "example.com/bad")+`']
+##teamcity[testSuiteFinished name='example.com/bad' flowId='example.com/bad']
+`, buff.String())
+}
+
+// fingerprintOf returns the SARIF fingerprint of the build failure of the package.
+func fingerprintOf(t *testing.T, pack string) string {
+	buff := bytes.NewBuffer(nil)
+	require.Nil(t, format.SARIF(report.Result{PackageResult: []report.PackageResult{{Name: report.PackageName(pack), PackageResult: report.FTSFail}}}, buff, format.Options{}))
+	var log sarifLog
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &log))
+	return log.Runs[0].Results[0].PartialFingerprints["goTestFailure/v1"]
+}
+
+const teamCityJson = `{"Time":"2024-01-01T10:00:00Z","Action":"start","Package":"example.com/a"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"example.com/a","Test":"TestB"}
+{"Time":"2024-01-01T10:00:01Z","Action":"output","Package":"example.com/a","Test":"TestB","Output":"    b_test.go:5: fail\n"}
+{"Time":"2024-01-01T10:00:01Z","Action":"fail","Package":"example.com/a","Test":"TestB","Elapsed":1}
+{"Time":"2024-01-01T10:00:02Z","Action":"output","Package":"example.com/a","Output":"panic: test timed out after 2s\n"}
+{"Time":"2024-01-01T10:00:02Z","Action":"fail","Package":"example.com/a","Elapsed":2}
+{"ImportPath":"example.com/bad [example.com/bad.test]","Action":"build-output","Output":"bad/bad.go:3:23: undefined: x\n"}
+{"ImportPath":"example.com/bad [example.com/bad.test]","Action":"build-fail"}
+{"Time":"2024-01-01T10:00:03Z","Action":"fail","Package":"example.com/bad","Elapsed":0}
+{"Time":"2024-01-01T10:00:04Z","Action":"run","Package":"example.com/c","Test":"TestC"}`
+
+func TestTeamCityStream(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+	stream := format.NewTeamCityStream(buff)
+
+	// Events are split across writes
+	for _, chunk := range strings.SplitAfter(teamCityJson, "Test") {
+		_, err := stream.Write([]byte(chunk))
+		require.Nil(t, err)
+	}
+	assert.NotContains(t, buff.String(), "TestC", "incomplete lines are not converted")
+	require.Nil(t, stream.Close())
+
+	assert.Equal(t, `##teamcity[testSuiteStarted name='example.com/a' flowId='example.com/a' timestamp='2024-01-01T10:00:00.000+0000']
+##teamcity[flowStarted flowId='example.com/a TestA' parent='example.com/a']
+##teamcity[testStarted name='TestA' captureStandardOutput='false' flowId='example.com/a TestA' timestamp='2024-01-01T10:00:00.000+0000']
+##teamcity[flowStarted flowId='example.com/a TestB' parent='example.com/a']
+##teamcity[testStarted name='TestB' captureStandardOutput='false' flowId='example.com/a TestB' timestamp='2024-01-01T10:00:00.000+0000']
+##teamcity[testFailed name='TestB' message='b_test.go:5: fail' details='    b_test.go:5: fail|n' flowId='example.com/a TestB']
+##teamcity[testFinished name='TestB' duration='1000' flowId='example.com/a TestB' timestamp='2024-01-01T10:00:01.000+0000']
+##teamcity[flowFinished flowId='example.com/a TestB']
+##teamcity[testFailed name='TestA' message='panic: test timed out after 2s' details='panic: test timed out after 2s|n' flowId='example.com/a TestA']
+##teamcity[testFinished name='TestA' duration='2000' flowId='example.com/a TestA' timestamp='2024-01-01T10:00:02.000+0000']
+##teamcity[flowFinished flowId='example.com/a TestA']
+##teamcity[testSuiteFinished name='example.com/a' flowId='example.com/a' timestamp='2024-01-01T10:00:02.000+0000']
+##teamcity[testSuiteStarted name='example.com/bad' flowId='example.com/bad' timestamp='2024-01-01T10:00:03.000+0000']
+##teamcity[buildProblem description='bad/bad.go:3:23: undefined: x' identity='`+fingerprintOf(t, "example.com/bad")+`']
+##teamcity[testSuiteFinished name='example.com/bad' flowId='example.com/bad' timestamp='2024-01-01T10:00:03.000+0000']
+##teamcity[testSuiteStarted name='example.com/c' flowId='example.com/c' timestamp='2024-01-01T10:00:04.000+0000']
+##teamcity[flowStarted flowId='example.com/c TestC' parent='example.com/c']
+##teamcity[testStarted name='TestC' captureStandardOutput='false' flowId='example.com/c TestC' timestamp='2024-01-01T10:00:04.000+0000']
+##teamcity[testFailed name='TestC' message='test did not finish' flowId='example.com/c TestC']
+##teamcity[testFinished name='TestC' duration='0' flowId='example.com/c TestC']
+##teamcity[flowFinished flowId='example.com/c TestC']
+##teamcity[testSuiteFinished name='example.com/c' flowId='example.com/c']
+`, buff.String())
+}
+
+func TestTeamCityStream_Reruns(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+	stream := format.NewTeamCityStream(buff)
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", Tests: []report.TestResult{
+			{Name: "TestFlaky", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSFail}, {TestResult: report.FTSPass}}},
+			{Name: "TestPass", TestResult: report.FTSPass},
+		}},
+		{Name: "example.com/b", Tests: []report.TestResult{{Name: "TestPass", TestResult: report.FTSPass}}},
+	}}
+
+	require.Nil(t, stream.Reruns(result))
+
+	assert.Equal(t, `##teamcity[testSuiteStarted name='example.com/a' flowId='example.com/a']
+##teamcity[testStarted name='TestFlaky' captureStandardOutput='false' flowId='example.com/a']
+##teamcity[testFailed name='TestFlaky' message='failed on rerun 1' flowId='example.com/a']
+##teamcity[testFinished name='TestFlaky' duration='0' flowId='example.com/a']
+##teamcity[testStarted name='TestFlaky' captureStandardOutput='false' flowId='example.com/a']
+##teamcity[testFinished name='TestFlaky' duration='0' flowId='example.com/a']
+##teamcity[testSuiteFinished name='example.com/a' flowId='example.com/a']
+`, buff.String())
+}
@@ -0,0 +1,122 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Microsoft Teams message with an Adaptive Card
+// https://learn.microsoft.com/en-us/microsoftteams/platform/task-modules-and-cards/cards/cards-reference#adaptive-card
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+	Actions []teamsAction  `json:"actions,omitempty"`
+	MSTeams map[string]any `json:"msteams,omitempty"`
+}
+
+type teamsElement struct {
+	Type      string         `json:"type"`
+	Text      string         `json:"text,omitempty"`
+	Size      string         `json:"size,omitempty"`
+	Weight    string         `json:"weight,omitempty"`
+	Color     string         `json:"color,omitempty"`
+	FontType  string         `json:"fontType,omitempty"`
+	IsSubtle  bool           `json:"isSubtle,omitempty"`
+	Wrap      bool           `json:"wrap,omitempty"`
+	Separator bool           `json:"separator,omitempty"`
+	Facts     []teamsFact    `json:"facts,omitempty"`
+	Items     []teamsElement `json:"items,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// teamsMaxSize is the maximum size of messages which are posted to Teams.
+const teamsMaxSize = 28 * 1024
+
+func teamsCardOf(result report.Result, failed []failure, more int) teamsMessage {
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		MSTeams: map[string]any{"width": "Full"},
+	}
+	facts := []teamsFact{
+		{"Total", fmt.Sprint(result.Tests)},
+		{"Passed", fmt.Sprint(result.Passed)},
+		{"Skipped", fmt.Sprint(result.Skipped)},
+		{"Failed", fmt.Sprint(result.Failed)},
+	}
+	if result.Flaky > 0 {
+		facts = append(facts, teamsFact{"Flaky", fmt.Sprint(result.Flaky)})
+	}
+	if result.Incomplete > 0 {
+		facts = append(facts, teamsFact{"Incomplete", fmt.Sprint(result.Incomplete)})
+	}
+	if result.Quarantined > 0 {
+		facts = append(facts, teamsFact{"Quarantined", fmt.Sprint(result.Quarantined)})
+	}
+	facts = append(facts, teamsFact{"Duration", result.Duration.String()})
+	card.Body = []teamsElement{
+		{Type: "TextBlock", Text: chatTitle(result), Size: "Large", Weight: "Bolder", Wrap: true},
+		{Type: "FactSet", Facts: facts},
+	}
+	for idx, f := range failed {
+		name, message := chatFailure(f)
+		card.Body = append(card.Body, teamsElement{Type: "Container", Separator: idx == 0, Items: []teamsElement{
+			{Type: "TextBlock", Text: "❌ " + name, Weight: "Bolder", Color: "Attention", Wrap: true},
+			{Type: "TextBlock", Text: string(f.pack.Name), IsSubtle: true, Wrap: true},
+			{Type: "TextBlock", Text: message, FontType: "Monospace", Wrap: true},
+		}})
+	}
+	if more > 0 {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: fmt.Sprintf("and %d more failures", more), IsSubtle: true})
+	}
+	if url := result.Vars["RunURL"]; url != "" {
+		card.Actions = []teamsAction{{Type: "Action.OpenUrl", Title: "View run", URL: url}}
+	}
+	return teamsMessage{Type: "message", Attachments: []teamsAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}}}
+}
+
+// Teams writes the result as Microsoft Teams message with an Adaptive Card which contains the summary, the
+// first failed tests and a link to the RunURL variable. Failures are left out if the message would exceed
+// the size limit of Teams.
+func Teams(result report.Result, out io.Writer, _ Options) error {
+	failed, more := chatFailures(result, maxChatFailures)
+	for {
+		buff := bytes.NewBuffer(nil)
+		if err := json.NewEncoder(buff).Encode(teamsCardOf(result, failed, more)); err != nil {
+			return err
+		}
+		if buff.Len() <= teamsMaxSize || len(failed) == 0 {
+			_, err := buff.WriteTo(out)
+			return err
+		}
+		failed = failed[:len(failed)-1]
+		more++
+	}
+}
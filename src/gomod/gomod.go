@@ -0,0 +1,112 @@
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Module struct {
+	Path string // module path from the go.mod file
+	Dir  string // directory of the go.mod file
+}
+
+// ModulePath returns the module path declared in the go.mod file.
+func ModulePath(goModFile string) (string, error) {
+	content, err := os.ReadFile(goModFile)
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 2 && fields[0] == "module" {
+			return unquote(fields[1]), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive in %s", goModFile)
+}
+
+// Modules returns the modules of the go.work file in the directory or the
+// module of the go.mod file if there is no workspace.
+func Modules(dir string) (modules []Module, err error) {
+	workFile := filepath.Join(dir, "go.work")
+	content, err := os.ReadFile(workFile)
+	if os.IsNotExist(err) {
+		path, err := ModulePath(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		return []Module{{Path: path, Dir: dir}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, useDir := range workspaceDirs(content) {
+		moduleDir := filepath.Join(dir, useDir)
+		path, err := ModulePath(filepath.Join(moduleDir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, Module{Path: path, Dir: moduleDir})
+	}
+	return modules, nil
+}
+
+// workspaceDirs returns the directories of all use directives. Both the single line
+// and the block form are supported.
+func workspaceDirs(goWork []byte) (dirs []string) {
+	inUseBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(goWork))
+	for scanner.Scan() {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		switch {
+		case len(fields) == 0:
+		case inUseBlock && fields[0] == ")":
+			inUseBlock = false
+		case inUseBlock:
+			dirs = append(dirs, unquote(fields[0]))
+		case fields[0] == "use" && len(fields) == 2 && fields[1] == "(":
+			inUseBlock = true
+		case fields[0] == "use" && len(fields) == 2:
+			dirs = append(dirs, unquote(fields[1]))
+		}
+	}
+	return dirs
+}
+
+func stripComment(line string) string {
+	if idx := strings.Index(line, "//"); idx >= 0 {
+		return line[:idx]
+	}
+	return line
+}
+
+func unquote(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+// PackageDir returns the directory of the package with the import path.
+// The most specific module is used for nested modules.
+func PackageDir(modules []Module, importPath string) (dir string, ok bool) {
+	var match *Module
+	for idx, module := range modules {
+		if importPath != module.Path && !strings.HasPrefix(importPath, module.Path+"/") {
+			continue
+		}
+		if match == nil || len(module.Path) > len(match.Path) {
+			match = &modules[idx]
+		}
+	}
+	if match == nil {
+		return "", false
+	}
+	return filepath.Join(match.Dir, filepath.FromSlash(strings.TrimPrefix(importPath, match.Path))), true
+}
@@ -0,0 +1,98 @@
+package gomod_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/gomod"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	require.Nil(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.Nil(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestModulePath(t *testing.T) {
+	var suite = []struct {
+		goMod string
+		path  string
+		isErr bool
+	}{
+		{"module github.com/foo/bar\n\ngo 1.18\n", "github.com/foo/bar", false},
+		{"// comment\nmodule \"example.com/quoted\" // trailing\n", "example.com/quoted", false},
+		{"go 1.18\n", "", true},
+	}
+	for _, s := range suite {
+		t.Run(s.path, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "go.mod")
+			writeFile(t, file, s.goMod)
+			path, err := gomod.ModulePath(file)
+			assert.Equal(t, s.isErr, err != nil)
+			assert.Equal(t, s.path, path)
+		})
+	}
+	_, err := gomod.ModulePath(filepath.Join(t.TempDir(), "go.mod"))
+	assert.NotNil(t, err)
+}
+
+func TestModules_GoMod(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/single\n")
+
+	modules, err := gomod.Modules(dir)
+	require.Nil(t, err)
+	assert.Equal(t, []gomod.Module{{Path: "example.com/single", Dir: dir}}, modules)
+}
+
+func TestModules_GoWork(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.work"), "go 1.18\n\nuse ./tools\n\nuse (\n\t./api // comment\n\t\"./storage\"\n)\n")
+	writeFile(t, filepath.Join(dir, "tools", "go.mod"), "module example.com/tools\n")
+	writeFile(t, filepath.Join(dir, "api", "go.mod"), "module example.com/api\n")
+	writeFile(t, filepath.Join(dir, "storage", "go.mod"), "module example.com/storage\n")
+
+	modules, err := gomod.Modules(dir)
+	require.Nil(t, err)
+	assert.Equal(t, []gomod.Module{
+		{Path: "example.com/tools", Dir: filepath.Join(dir, "tools")},
+		{Path: "example.com/api", Dir: filepath.Join(dir, "api")},
+		{Path: "example.com/storage", Dir: filepath.Join(dir, "storage")},
+	}, modules)
+
+	require.Nil(t, os.Remove(filepath.Join(dir, "api", "go.mod")))
+	_, err = gomod.Modules(dir)
+	assert.NotNil(t, err)
+}
+
+func TestModules_None(t *testing.T) {
+	_, err := gomod.Modules(t.TempDir())
+	assert.NotNil(t, err)
+}
+
+func TestPackageDir(t *testing.T) {
+	modules := []gomod.Module{
+		{Path: "example.com/repo", Dir: "root"},
+		{Path: "example.com/repo/tools", Dir: filepath.Join("root", "tools")},
+	}
+	var suite = []struct {
+		importPath string
+		dir        string
+		ok         bool
+	}{
+		{"example.com/repo", "root", true},
+		{"example.com/repo/api/users", filepath.Join("root", "api", "users"), true},
+		{"example.com/repo/tools/gen", filepath.Join("root", "tools", "gen"), true},
+		{"example.com/repository", "", false},
+		{"other.com/x", "", false},
+	}
+	for _, s := range suite {
+		t.Run(s.importPath, func(t *testing.T) {
+			dir, ok := gomod.PackageDir(modules, s.importPath)
+			assert.Equal(t, s.ok, ok)
+			assert.Equal(t, s.dir, dir)
+		})
+	}
+}
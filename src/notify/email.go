@@ -0,0 +1,325 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+//go:embed templates/email.html
+var emailHTML string
+
+//go:embed templates/email.txt
+var emailText string
+
+var (
+	emailHTMLTemplate = template.Must(template.New("email.html").Parse(emailHTML))
+	emailTextTemplate = texttemplate.Must(texttemplate.New("email.txt").Parse(emailText))
+)
+
+// TLS modes of the connection to the SMTP server
+const (
+	StartTLS = "starttls" // upgrade the connection with STARTTLS, which the server must support
+	TLS      = "tls"      // connect with implicit TLS, usually on port 465
+	NoTLS    = "none"     // send unencrypted, for example to a local relay
+)
+
+// maxEmailFailures is the number of failures with output in the email.
+const maxEmailFailures = 20
+
+// maxEmailOutputLines is the number of last output lines of every failure in the email.
+const maxEmailOutputLines = 30
+
+// Email is an SMTP server and the recipients of the report. Environment variables such as ${SMTP_PASSWORD} in the
+// host, username and password are expanded.
+type Email struct {
+	// Host and port of the SMTP server such as smtp.example.com:587.
+	Host     string   `json:"host"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	// Subject of the email. Defaults to the title and the status of the run.
+	Subject string `json:"subject,omitempty"`
+	// On is always, failure or change. Defaults to always.
+	On Condition `json:"on,omitempty"`
+	// TLS is starttls, tls or none. Defaults to starttls.
+	TLS string `json:"tls,omitempty"`
+	// Timeout of the connection to the SMTP server such as "30s". Defaults to 30s.
+	Timeout string `json:"timeout,omitempty"`
+
+	// TLSConfig is used to verify the server. Defaults to the system roots and the host name of Host.
+	TLSConfig *tls.Config `json:"-"`
+
+	timeout time.Duration
+}
+
+func LoadEmail(pathToFile string) (email Email, err error) {
+	content, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return Email{}, err
+	}
+	return ParseEmail(content)
+}
+
+func ParseEmail(content []byte) (email Email, err error) {
+	if err := json.Unmarshal(content, &email); err != nil {
+		return Email{}, fmt.Errorf("invalid email configuration. %s", err)
+	}
+	email.Host = os.ExpandEnv(email.Host)
+	email.Username = os.ExpandEnv(email.Username)
+	email.Password = os.ExpandEnv(email.Password)
+	if _, _, err := net.SplitHostPort(email.Host); err != nil {
+		return Email{}, fmt.Errorf("email host must have the form <host>:<port>, got %q", email.Host)
+	}
+	if _, err := mail.ParseAddress(email.From); err != nil {
+		return Email{}, fmt.Errorf("invalid from address %q. %s", email.From, err)
+	}
+	if len(email.To) == 0 {
+		return Email{}, fmt.Errorf("email must have at least one recipient")
+	}
+	for _, to := range email.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return Email{}, fmt.Errorf("invalid to address %q. %s", to, err)
+		}
+	}
+	if email.On, err = parseCondition(email.On); err != nil {
+		return Email{}, fmt.Errorf("email %s", err)
+	}
+	switch email.TLS {
+	case "":
+		email.TLS = StartTLS
+	case StartTLS, TLS, NoTLS:
+	default:
+		return Email{}, fmt.Errorf("unknown email tls mode %s. Expected starttls, tls or none", email.TLS)
+	}
+	if email.timeout, err = parseDuration(email.Timeout, 30*time.Second); err != nil {
+		return Email{}, fmt.Errorf("email must have a positive timeout such as 30s, got %q", email.Timeout)
+	}
+	return email, nil
+}
+
+// emailTest is a failed or flaky test or a package which failed without failed tests.
+type emailTest struct {
+	Package     report.PackageName
+	Name        string // empty for packages
+	Output      string // last lines of the output
+	Attempts    int
+	Quarantined bool
+}
+
+type emailData struct {
+	Result       report.Result
+	Title        string
+	RunFailed    bool
+	RunURL       string
+	Failures     []emailTest
+	MoreFailures int
+	Flaky        []emailTest
+}
+
+func lastLines(lines []report.OutputLine, max int) string {
+	var text []string
+	for _, line := range lines {
+		if t := strings.TrimRight(line.Text, "\r\n"); t != "" && !strings.HasPrefix(t, "=== ") {
+			text = append(text, t)
+		}
+	}
+	if len(text) > max {
+		text = append([]string{"..."}, text[len(text)-max:]...)
+	}
+	return strings.Join(text, "\n")
+}
+
+func newEmailData(result report.Result) emailData {
+	data := emailData{Result: result, Title: result.Vars["Title"], RunFailed: Failed(result), RunURL: result.Vars["RunURL"]}
+	if data.Title == "" {
+		data.Title = "Test Report"
+	}
+	add := func(test emailTest) {
+		if len(data.Failures) < maxEmailFailures {
+			data.Failures = append(data.Failures, test)
+		} else {
+			data.MoreFailures++
+		}
+	}
+	for _, pack := range result.PackageResult {
+		failed := make(map[string]bool)
+		for _, name := range pack.FailedTestNames() {
+			failed[name] = true
+		}
+		for _, test := range pack.Tests {
+			switch {
+			case test.Flaky():
+				data.Flaky = append(data.Flaky, emailTest{Package: pack.Name, Name: test.Name, Attempts: len(test.Attempts)})
+			case failed[test.Name] || test.TestResult == report.FTSIncomplete:
+				add(emailTest{Package: pack.Name, Name: test.Name, Output: lastLines(test.Output, maxEmailOutputLines),
+					Attempts: len(test.Attempts), Quarantined: test.Quarantine != nil})
+			}
+		}
+		if pack.PackageResult == report.FTSFail && len(failed) == 0 && pack.Failed() {
+			add(emailTest{Package: pack.Name, Output: lastLines(pack.Output, maxEmailOutputLines)})
+		}
+	}
+	return data
+}
+
+// subject returns the configured subject or the title with the status of the run.
+func (e Email) subject(data emailData) string {
+	switch {
+	case e.Subject != "":
+		return e.Subject
+	case !data.RunFailed:
+		return fmt.Sprintf("✔️ %s passed", data.Title)
+	case data.Result.Failed > 0:
+		return fmt.Sprintf("❌ %s: %d of %d tests failed", data.Title, data.Result.Failed, data.Result.Tests)
+	default:
+		return fmt.Sprintf("❌ %s failed", data.Title)
+	}
+}
+
+// RenderEmail returns the HTML body with inline styles and the plain text body of the email.
+func RenderEmail(result report.Result) (html, text string, err error) {
+	data := newEmailData(result)
+	htmlBody, textBody := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	if err := emailHTMLTemplate.Execute(htmlBody, data); err != nil {
+		return "", "", err
+	}
+	if err := emailTextTemplate.Execute(textBody, data); err != nil {
+		return "", "", err
+	}
+	return htmlBody.String(), textBody.String(), nil
+}
+
+// Message returns the MIME message with a plain text and an HTML alternative.
+func (e Email) Message(result report.Result, date time.Time) ([]byte, error) {
+	html, text, err := RenderEmail(result)
+	if err != nil {
+		return nil, err
+	}
+	parts := bytes.NewBuffer(nil)
+	body := multipart.NewWriter(parts)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(writer)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	msg := bytes.NewBuffer(nil)
+	headers := []struct{ key, value string }{
+		{"From", e.From},
+		{"To", strings.Join(e.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", e.subject(newEmailData(result)))},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + body.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(msg, "%s: %s\r\n", header.key, header.value)
+	}
+	msg.WriteString("\r\n")
+	msg.Write(parts.Bytes())
+	return msg.Bytes(), nil
+}
+
+// Send sends the report to the recipients. net/smtp only authenticates over TLS or with localhost.
+func (e Email) Send(result report.Result) error {
+	msg, err := e.Message(result, time.Now())
+	if err != nil {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(e.Host)
+	tlsConfig := e.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+
+	dialer := &net.Dialer{Timeout: e.timeout}
+	var conn net.Conn
+	if e.TLS == TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", e.Host, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", e.Host)
+	}
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(e.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if e.TLS == StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server %s does not support STARTTLS", e.Host)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return err
+		}
+	}
+	from, _ := mail.ParseAddress(e.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		address, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(address.Address); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
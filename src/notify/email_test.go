@@ -0,0 +1,255 @@
+package notify_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/notify"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession is what the SMTP stub received.
+type smtpSession struct {
+	tls  bool
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// smtpStub accepts a single SMTP session. STARTTLS is offered if tlsConfig is set.
+func smtpStub(t *testing.T, tlsConfig *tls.Config) (addr string, session <-chan smtpSession) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := smtpSession{}
+		text := textproto.NewConn(conn)
+		reply := func(line string) { text.PrintfLine("%s", line) }
+		reply("220 stub ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(cmd) {
+			case "EHLO":
+				if tlsConfig != nil && !s.tls {
+					reply("250-stub")
+					reply("250 STARTTLS")
+				} else {
+					reply("250-stub")
+					reply("250 AUTH PLAIN")
+				}
+			case "STARTTLS":
+				reply("220 ready")
+				tlsConn := tls.Server(conn, tlsConfig)
+				if err := tlsConn.Handshake(); err != nil {
+					return
+				}
+				conn, text, s.tls = tlsConn, textproto.NewConn(tlsConn), true
+			case "AUTH":
+				auth, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+				s.auth = string(auth)
+				reply("235 ok")
+			case "MAIL":
+				s.from = arg
+				reply("250 ok")
+			case "RCPT":
+				s.to = append(s.to, arg)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				s.data = string(data)
+				reply("250 ok")
+			case "QUIT":
+				reply("221 bye")
+				sessions <- s
+				return
+			default:
+				reply("502 unknown command")
+			}
+		}
+	}()
+	return listener.Addr().String(), sessions
+}
+
+// parts returns the content of the parts of the multipart message by content type.
+func parts(t *testing.T, msg *mail.Message) map[string]string {
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+	result := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return result
+		}
+		require.Nil(t, err)
+		content, err := io.ReadAll(part)
+		require.Nil(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		result[contentType] = string(content)
+	}
+}
+
+func receive(t *testing.T, session <-chan smtpSession) smtpSession {
+	select {
+	case s := <-session:
+		return s
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no mail received")
+		return smtpSession{}
+	}
+}
+
+func TestParseEmail(t *testing.T) {
+	t.Setenv("SMTP_PASSWORD", "secret")
+
+	email, err := notify.ParseEmail([]byte(`{"host": "smtp.example.com:587", "username": "ci", "password": "${SMTP_PASSWORD}",
+		"from": "CI <ci@example.com>", "to": ["team@example.com"], "on": "failure"}`))
+
+	require.Nil(t, err)
+	assert.Equal(t, "secret", email.Password)
+	assert.Equal(t, notify.OnFailure, email.On)
+	assert.Equal(t, notify.StartTLS, email.TLS)
+}
+
+func TestParseEmail_Error(t *testing.T) {
+	var suite = []string{
+		`[]`,
+		`{"host": "smtp.example.com", "from": "ci@example.com", "to": ["team@example.com"]}`,
+		`{"host": "smtp.example.com:587", "from": "ci", "to": ["team@example.com"]}`,
+		`{"host": "smtp.example.com:587", "from": "ci@example.com"}`,
+		`{"host": "smtp.example.com:587", "from": "ci@example.com", "to": ["team"]}`,
+		`{"host": "smtp.example.com:587", "from": "ci@example.com", "to": ["team@example.com"], "on": "success"}`,
+		`{"host": "smtp.example.com:587", "from": "ci@example.com", "to": ["team@example.com"], "tls": "ssl"}`,
+		`{"host": "smtp.example.com:587", "from": "ci@example.com", "to": ["team@example.com"], "timeout": "0s"}`,
+	}
+	for _, s := range suite {
+		t.Run(s, func(t *testing.T) {
+			_, err := notify.ParseEmail([]byte(s))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestRenderEmail(t *testing.T) {
+	result := report.Result{Tests: 2, Failed: 1, Flaky: 1, Vars: map[string]string{"Title": "Nightly <main>", "RunURL": "https://ci.example.com/run/1"},
+		PackageResult: []report.PackageResult{{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestA", TestResult: report.FTSFail, Output: []report.OutputLine{{Text: "=== RUN   TestA\n"}, {Text: "    a_test.go:12: got <script>alert(1)</script>\n"}},
+				Attempts: []report.Attempt{{TestResult: report.FTSFail}}},
+			{Name: "TestB", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}},
+		}}, {Name: "example.com/b", PackageResult: report.FTSFail, Output: []report.OutputLine{{Text: "b.go:3:2: undefined: foo\n"}}}},
+	}
+
+	html, text, err := notify.RenderEmail(result)
+
+	require.Nil(t, err)
+	assert.Contains(t, html, "Nightly &lt;main&gt;")
+	assert.Contains(t, html, "got &lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, html, "<script")
+	assert.NotContains(t, html, "<style")
+	assert.Contains(t, html, `href="https://ci.example.com/run/1"`)
+	assert.Contains(t, html, "undefined: foo")
+	assert.NotContains(t, html, "=== RUN")
+
+	assert.Contains(t, text, "Nightly <main>: FAILED")
+	assert.Contains(t, text, "TestA (example.com/a) [failed 1 reruns]\n    a_test.go:12: got <script>alert(1)</script>")
+	assert.Contains(t, text, "example.com/b\nb.go:3:2: undefined: foo")
+	assert.Contains(t, text, "TestB (example.com/a) passed on rerun 1")
+	assert.Contains(t, text, "Run: https://ci.example.com/run/1")
+}
+
+func TestRenderEmail_MoreFailures(t *testing.T) {
+	pack := report.PackageResult{Name: "example.com/a", PackageResult: report.FTSFail}
+	for idx := 0; idx < 25; idx++ {
+		pack.Tests = append(pack.Tests, report.TestResult{Name: "Test" + string(rune('A'+idx)), TestResult: report.FTSFail})
+	}
+
+	html, text, err := notify.RenderEmail(report.Result{PackageResult: []report.PackageResult{pack}})
+
+	require.Nil(t, err)
+	assert.Contains(t, html, "and 5 more failures")
+	assert.Contains(t, text, "and 5 more failures")
+	assert.NotContains(t, text, "TestY")
+}
+
+func TestEmailSend(t *testing.T) {
+	addr, session := smtpStub(t, nil)
+	email, err := notify.ParseEmail([]byte(`{"host": "` + addr + `", "from": "CI <ci@example.com>", "to": ["a@example.com", "B <b@example.com>"], "tls": "none"}`))
+	require.Nil(t, err)
+
+	require.Nil(t, email.Send(failed))
+
+	s := receive(t, session)
+	assert.False(t, s.tls)
+	assert.Empty(t, s.auth)
+	assert.Equal(t, "FROM:<ci@example.com>", s.from)
+	assert.Equal(t, []string{"TO:<a@example.com>", "TO:<b@example.com>"}, s.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(s.data))
+	require.Nil(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.Nil(t, err)
+	assert.Equal(t, "❌ Test Report: 1 of 1 tests failed", subject)
+	assert.Equal(t, "a@example.com, B <b@example.com>", msg.Header.Get("To"))
+	content := parts(t, msg)
+	assert.Contains(t, content["text/plain"], "Test Report: FAILED")
+	assert.Contains(t, content["text/html"], "<!DOCTYPE html>")
+}
+
+func TestEmailSend_StartTLS(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	addr, session := smtpStub(t, &tls.Config{Certificates: server.TLS.Certificates})
+	email, err := notify.ParseEmail([]byte(`{"host": "` + addr + `", "username": "ci", "password": "secret", "from": "ci@example.com",
+		"to": ["team@example.com"], "subject": "Nightly"}`))
+	require.Nil(t, err)
+	email.TLSConfig = &tls.Config{RootCAs: roots, ServerName: "example.com"}
+
+	require.Nil(t, email.Send(passed))
+
+	s := receive(t, session)
+	assert.True(t, s.tls)
+	assert.Equal(t, "\x00ci\x00secret", s.auth)
+	msg, err := mail.ReadMessage(strings.NewReader(s.data))
+	require.Nil(t, err)
+	assert.Equal(t, "Nightly", msg.Header.Get("Subject"))
+}
+
+func TestEmailSend_StartTLSNotSupported(t *testing.T) {
+	addr, _ := smtpStub(t, nil)
+	email, err := notify.ParseEmail([]byte(`{"host": "` + addr + `", "from": "ci@example.com", "to": ["team@example.com"]}`))
+	require.Nil(t, err)
+
+	assert.NotNil(t, email.Send(passed))
+}
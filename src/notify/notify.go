@@ -0,0 +1,277 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Condition defines when a notification is sent.
+type Condition string
+
+const (
+	Always    Condition = "always"  // after every run
+	OnFailure Condition = "failure" // if a package failed
+	OnChange  Condition = "change"  // if the run failed and the previous run passed or the other way round
+)
+
+// SignatureHeader contains the HMAC-SHA256 signature of the body in the form sha256=<hex>.
+const SignatureHeader = "X-Signature-256"
+
+// StatusHeader contains the status of the run: passed or failed.
+const StatusHeader = "X-Test-Status"
+
+// Endpoint is an HTTP endpoint to which the JSON encoded result is posted. Environment variables such as ${TOKEN}
+// in the URL, headers and secret are expanded.
+type Endpoint struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Secret to sign the body with HMAC-SHA256. The body is not signed if empty.
+	Secret string `json:"secret,omitempty"`
+	// On is always, failure or change. Defaults to always.
+	On Condition `json:"on,omitempty"`
+	// Timeout of a single request such as "10s". Defaults to 10s.
+	Timeout string `json:"timeout,omitempty"`
+	// Retries is the number of times a failed request is retried.
+	Retries int `json:"retries,omitempty"`
+	// Backoff is the wait time before the first retry such as "1s". It doubles with every retry. Defaults to 1s.
+	Backoff string `json:"backoff,omitempty"`
+
+	timeout time.Duration
+	backoff time.Duration
+}
+
+// Endpoints are notified in order.
+type Endpoints []Endpoint
+
+func Load(pathToFile string) (endpoints Endpoints, err error) {
+	content, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(content)
+}
+
+func Parse(content []byte) (endpoints Endpoints, err error) {
+	if err := json.Unmarshal(content, &endpoints); err != nil {
+		return nil, fmt.Errorf("invalid notify endpoints. %s", err)
+	}
+	for idx := range endpoints {
+		endpoint := &endpoints[idx]
+		endpoint.URL = os.ExpandEnv(endpoint.URL)
+		if !strings.HasPrefix(endpoint.URL, "http://") && !strings.HasPrefix(endpoint.URL, "https://") {
+			return nil, fmt.Errorf("endpoint %d must have an http or https url", idx)
+		}
+		for key, value := range endpoint.Headers {
+			endpoint.Headers[key] = os.ExpandEnv(value)
+		}
+		endpoint.Secret = os.ExpandEnv(endpoint.Secret)
+		if endpoint.On, err = parseCondition(endpoint.On); err != nil {
+			return nil, fmt.Errorf("endpoint %d %s", idx, err)
+		}
+		if endpoint.timeout, err = parseDuration(endpoint.Timeout, 10*time.Second); err != nil {
+			return nil, fmt.Errorf("endpoint %d must have a positive timeout such as 10s, got %q", idx, endpoint.Timeout)
+		}
+		if endpoint.backoff, err = parseDuration(endpoint.Backoff, time.Second); err != nil {
+			return nil, fmt.Errorf("endpoint %d must have a positive backoff such as 1s, got %q", idx, endpoint.Backoff)
+		}
+		if endpoint.Retries < 0 {
+			return nil, fmt.Errorf("endpoint %d must not have negative retries", idx)
+		}
+	}
+	return endpoints, nil
+}
+
+func parseCondition(c Condition) (Condition, error) {
+	switch c {
+	case "":
+		return Always, nil
+	case Always, OnFailure, OnChange:
+		return c, nil
+	default:
+		return "", fmt.Errorf("has unknown condition %s. Expected always, failure or change", c)
+	}
+}
+
+func parseDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("duration must be positive")
+	}
+	return d, err
+}
+
+// Failed is true if a package failed. Failures of quarantined and flaky tests are ignored.
+func Failed(result report.Result) bool {
+	for _, pack := range result.PackageResult {
+		if pack.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// State is the status of a run which is stored for the change condition. It is computed after reruns and
+// quarantine were applied, so that failures of flaky and quarantined tests are ignored like in the current run.
+type State struct {
+	Failed bool      `json:"failed"`
+	Time   time.Time `json:"time"`
+}
+
+// NewState returns the state of the result.
+func NewState(result report.Result, t time.Time) State {
+	return State{Failed: Failed(result), Time: t}
+}
+
+// LoadState reads the state of the previous run. A missing file, such as in the first run, returns nil.
+func LoadState(pathToFile string) (*State, error) {
+	content, err := os.ReadFile(pathToFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("invalid notify state %s. %s", pathToFile, err)
+	}
+	return &state, nil
+}
+
+// Save writes the state to the file.
+func (s State) Save(pathToFile string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pathToFile, append(content, '\n'), 0o644)
+}
+
+// Matches is true if a notification shall be sent for the result. Without a previous state, the status
+// is treated as changed.
+func (c Condition) Matches(result report.Result, previous *State) bool {
+	switch c {
+	case OnFailure:
+		return Failed(result)
+	case OnChange:
+		return previous == nil || Failed(result) != previous.Failed
+	default:
+		return true
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// maxRetryAfter limits the wait time which is requested by the Retry-After header.
+const maxRetryAfter = time.Minute
+
+// retryable is true for server errors and rate limits.
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// send posts the body once. The wait time of the Retry-After header is returned for retryable responses.
+func (e Endpoint) send(body []byte, status string) (retry bool, retryAfter time.Duration, err error) {
+	req, err := http.NewRequest(http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(StatusHeader, status)
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+	if e.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(e.Secret, body))
+	}
+	client := &http.Client{Timeout: e.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, 0, withoutURL(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, 0, nil
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return retryable(resp.StatusCode), retryAfter, fmt.Errorf("unexpected status %s. %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// withoutURL removes the URL from errors of the http client, because the URL may contain secrets.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// Send posts the result and retries failed requests with exponential backoff.
+func (e Endpoint) Send(result report.Result) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	status := "passed"
+	if Failed(result) {
+		status = "failed"
+	}
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		retry, retryAfter, err := e.send(body, status)
+		if err == nil || !retry || attempt >= e.Retries {
+			return err
+		}
+		if retryAfter > maxRetryAfter {
+			retryAfter = maxRetryAfter
+		}
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+// Notify sends the result to all matching endpoints. All endpoints are tried, even if sending to one fails.
+// The URLs are not part of the error, because they may contain secrets.
+func (e Endpoints) Notify(result report.Result, previous *State) error {
+	var errs []string
+	for idx, endpoint := range e {
+		if !endpoint.On.Matches(result, previous) {
+			continue
+		}
+		if err := endpoint.Send(result); err != nil {
+			errs = append(errs, fmt.Sprintf("endpoint %d: %s", idx, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/notify"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	passed = report.Result{Tests: 1, Passed: 1, PackageResult: []report.PackageResult{{Name: "example.com/a", PackageResult: report.FTSPass,
+		Tests: []report.TestResult{{Name: "TestA", TestResult: report.FTSPass}}}}}
+	failed = report.Result{Tests: 1, Failed: 1, PackageResult: []report.PackageResult{{Name: "example.com/a", PackageResult: report.FTSFail,
+		Tests: []report.TestResult{{Name: "TestA", TestResult: report.FTSFail}}}}}
+	flaky = report.Result{Tests: 1, Failed: 1, Flaky: 1, PackageResult: []report.PackageResult{{Name: "example.com/a", PackageResult: report.FTSFail,
+		Tests: []report.TestResult{{Name: "TestA", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}}}}}}
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("NOTIFY_TOKEN", "token")
+	t.Setenv("NOTIFY_SECRET", "secret")
+
+	endpoints, err := notify.Parse([]byte(`[
+		{"url": "https://ci.example.com/hook", "headers": {"Authorization": "Bearer ${NOTIFY_TOKEN}"}, "secret": "$NOTIFY_SECRET", "on": "failure", "retries": 2},
+		{"url": "http://localhost:8080"}
+	]`))
+
+	require.Nil(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token"}, endpoints[0].Headers)
+	assert.Equal(t, "secret", endpoints[0].Secret)
+	assert.Equal(t, notify.OnFailure, endpoints[0].On)
+	assert.Equal(t, 2, endpoints[0].Retries)
+	assert.Equal(t, notify.Always, endpoints[1].On)
+}
+
+func TestParse_Error(t *testing.T) {
+	var suite = []string{
+		`{}`,
+		`[{"url": "ftp://example.com"}]`,
+		`[{"url": "https://example.com", "on": "success"}]`,
+		`[{"url": "https://example.com", "timeout": "10"}]`,
+		`[{"url": "https://example.com", "timeout": "-1s"}]`,
+		`[{"url": "https://example.com", "backoff": "x"}]`,
+		`[{"url": "https://example.com", "retries": -1}]`,
+	}
+	for _, s := range suite {
+		t.Run(s, func(t *testing.T) {
+			_, err := notify.Parse([]byte(s))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestConditionMatches(t *testing.T) {
+	passedState, failedState, flakyState := notify.NewState(passed, time.Time{}), notify.NewState(failed, time.Time{}), notify.NewState(flaky, time.Time{})
+	var suite = []struct {
+		on       notify.Condition
+		result   report.Result
+		previous *notify.State
+		matches  bool
+	}{
+		{notify.Always, passed, nil, true},
+		{notify.OnFailure, passed, nil, false},
+		{notify.OnFailure, failed, nil, true},
+		{notify.OnFailure, flaky, nil, false},
+		{notify.OnChange, passed, nil, true},
+		{notify.OnChange, passed, &passedState, false},
+		{notify.OnChange, failed, &passedState, true},
+		{notify.OnChange, passed, &failedState, true},
+		{notify.OnChange, failed, &failedState, false},
+		{notify.OnChange, flaky, &passedState, false},
+		// Flaky and quarantined failures of the previous run are not stored as failure
+		{notify.OnChange, flaky, &flakyState, false},
+	}
+	for _, s := range suite {
+		t.Run(string(s.on), func(t *testing.T) {
+			assert.Equal(t, s.matches, s.on.Matches(s.result, s.previous))
+		})
+	}
+}
+
+func TestState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := notify.LoadState(file)
+	require.Nil(t, err)
+	assert.Nil(t, state)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Nil(t, notify.NewState(failed, now).Save(file))
+	state, err = notify.LoadState(file)
+	require.Nil(t, err)
+	assert.Equal(t, &notify.State{Failed: true, Time: now}, state)
+	assert.False(t, notify.NewState(flaky, now).Failed)
+
+	require.Nil(t, os.WriteFile(file, []byte(`{"Action":"pass"`), 0o644))
+	_, err = notify.LoadState(file)
+	assert.NotNil(t, err)
+}
+
+func endpoint(t *testing.T, url, config string) notify.Endpoint {
+	endpoints, err := notify.Parse([]byte(`[{"url": "` + url + `", "backoff": "1ms"` + config + `}]`))
+	require.Nil(t, err)
+	return endpoints[0]
+}
+
+func TestSend(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "failed", r.Header.Get(notify.StatusHeader))
+		assert.Equal(t, "value", r.Header.Get("X-Custom"))
+		assert.Equal(t, "sha256="+notify.Sign("secret", body), r.Header.Get(notify.SignatureHeader))
+	}))
+	defer server.Close()
+
+	require.Nil(t, endpoint(t, server.URL, `, "secret": "secret", "headers": {"X-Custom": "value"}`).Send(failed))
+
+	var result report.Result
+	require.Nil(t, json.Unmarshal(body, &result))
+	assert.Equal(t, failed, result)
+}
+
+func TestSend_ErrorWithoutURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	err := endpoint(t, server.URL+"/hook?token=SECRET", `, "retries": 0`).Send(passed)
+
+	require.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "SECRET")
+	assert.NotContains(t, err.Error(), "/hook")
+}
+
+func TestSign(t *testing.T) {
+	// Example of the GitHub webhook documentation
+	assert.Equal(t, "757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", notify.Sign("It's a Secret to Everybody", []byte("Hello, World!")))
+}
+
+func TestSend_Retry(t *testing.T) {
+	var suite = []struct {
+		name     string
+		statuses []int
+		retries  int
+		requests int32
+		isErr    bool
+	}{
+		{"server error", []int{http.StatusServiceUnavailable, http.StatusOK}, 2, 2, false},
+		{"rate limit", []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, 2, 3, false},
+		{"exhausted", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 2, 3, true},
+		{"no retries", []int{http.StatusInternalServerError}, 0, 1, true},
+		{"client error", []int{http.StatusBadRequest}, 2, 1, true},
+	}
+	for _, s := range suite {
+		t.Run(s.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				idx := atomic.AddInt32(&requests, 1) - 1
+				w.WriteHeader(s.statuses[idx])
+			}))
+			defer server.Close()
+
+			err := endpoint(t, server.URL, `, "retries": `+strconv.Itoa(s.retries)).Send(passed)
+
+			assert.Equal(t, s.isErr, err != nil)
+			assert.Equal(t, s.requests, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/error" {
+			http.Error(w, "rejected", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	endpoints := notify.Endpoints{
+		endpoint(t, server.URL+"/error", ""),
+		endpoint(t, server.URL+"/failure", `, "on": "failure"`),
+		endpoint(t, server.URL+"/change", `, "on": "change"`),
+	}
+
+	previous := notify.NewState(passed, time.Time{})
+	err := endpoints.Notify(failed, &previous)
+
+	require.NotNil(t, err)
+	assert.Equal(t, "endpoint 0: unexpected status 400 Bad Request. rejected", err.Error())
+	assert.False(t, strings.Contains(err.Error(), server.URL), "URLs are not part of the error")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	atomic.StoreInt32(&requests, 0)
+	assert.NotNil(t, endpoints.Notify(passed, &previous))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
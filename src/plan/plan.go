@@ -0,0 +1,270 @@
+package plan
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Plan is the list of shards which together run all planned packages.
+type Plan struct {
+	Shards []Shard `json:"shards"`
+}
+
+// Shard is the set of packages and tests which is run by a single CI job.
+type Shard struct {
+	Index int `json:"index"`
+	// Estimated duration such as "1m30s".
+	Estimate string        `json:"estimate"`
+	Duration time.Duration `json:"-"`
+	// Packages whose tests are all run by this shard.
+	Packages []string `json:"packages"`
+	// Packages whose tests are split across multiple shards.
+	Split []Split `json:"split,omitempty"`
+	// Go test commands which run the tests of the shard.
+	Commands []string `json:"commands"`
+}
+
+// Split is the part of a package which is run by a shard. Either the tests matching Run
+// are run or all tests except the ones matching Skip.
+type Split struct {
+	Package string `json:"package"`
+	Run     string `json:"run,omitempty"`
+	Skip    string `json:"skip,omitempty"`
+}
+
+// ReadPackages reads a list of package import paths such as the output of go list. One package per line.
+func ReadPackages(in io.Reader) (packages []string, err error) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			packages = append(packages, line)
+		}
+	}
+	return packages, scanner.Err()
+}
+
+// Average merges past test results. The durations of packages and top level tests are averaged over
+// the results which contain them. If packages is not empty, only these packages are kept and packages
+// without history are estimated with the average package duration.
+func Average(results []report.Result, packages []string) (average report.Result) {
+	type history struct {
+		pack     report.PackageResult
+		runs     int
+		testIdx  map[string]int
+		testRuns []int
+	}
+	var order []report.PackageName
+	histories := make(map[report.PackageName]*history)
+	for _, result := range results {
+		for _, pack := range result.PackageResult {
+			h, ok := histories[pack.Name]
+			if !ok {
+				h = &history{pack: report.PackageResult{Name: pack.Name}, testIdx: make(map[string]int)}
+				histories[pack.Name] = h
+				order = append(order, pack.Name)
+			}
+			h.runs++
+			h.pack.Duration += pack.Duration
+			for _, test := range pack.Tests {
+				if strings.Contains(test.Name, "/") {
+					continue
+				}
+				tIdx, ok := h.testIdx[test.Name]
+				if !ok {
+					tIdx = len(h.pack.Tests)
+					h.testIdx[test.Name] = tIdx
+					h.pack.Tests = append(h.pack.Tests, report.TestResult{Name: test.Name})
+					h.testRuns = append(h.testRuns, 0)
+				}
+				h.testRuns[tIdx]++
+				h.pack.Tests[tIdx].Duration += test.Duration
+			}
+		}
+	}
+
+	var total time.Duration
+	for _, h := range histories {
+		h.pack.Duration /= time.Duration(h.runs)
+		for idx := range h.pack.Tests {
+			h.pack.Tests[idx].Duration /= time.Duration(h.testRuns[idx])
+		}
+		total += h.pack.Duration
+	}
+
+	if len(packages) == 0 {
+		for _, name := range order {
+			average.PackageResult = append(average.PackageResult, histories[name].pack)
+		}
+		return average
+	}
+	var estimate time.Duration
+	if len(histories) > 0 {
+		estimate = total / time.Duration(len(histories))
+	}
+	for _, name := range packages {
+		if h, ok := histories[report.PackageName(name)]; ok {
+			average.PackageResult = append(average.PackageResult, h.pack)
+		} else {
+			average.PackageResult = append(average.PackageResult, report.PackageResult{Name: report.PackageName(name), Duration: estimate})
+		}
+	}
+	return average
+}
+
+// minTestShare is the share of the package duration which its top level tests must take for the package to be split.
+// The rest, such as the build and the setup of the package, is paid by every shard which runs a part of it.
+const minTestShare = 0.8
+
+// item is a package or a top level test of a package which is assigned to a single shard.
+// The overhead of a test is the part of the package duration which is not spent in its tests.
+type item struct {
+	pack     int
+	test     string
+	duration time.Duration
+	overhead time.Duration
+}
+
+// New distributes the packages of the result across the shards so that the longest shard is as short as possible.
+// Packages which take longer than the average shard are split into their top level tests if the tests take most of
+// the package duration. The first shard which runs a part of a split package skips the tests of the other shards,
+// so that tests without history are run too.
+func New(result report.Result, shards int) Plan {
+	var total time.Duration
+	for _, pack := range result.PackageResult {
+		total += pack.Duration
+	}
+	target := total / time.Duration(shards)
+
+	var items []item
+	for pIdx, pack := range result.PackageResult {
+		var tests time.Duration
+		for _, test := range pack.Tests {
+			tests += test.Duration
+		}
+		if pack.Duration <= target || len(pack.Tests) < 2 || float64(tests) < minTestShare*float64(pack.Duration) {
+			items = append(items, item{pack: pIdx, duration: pack.Duration})
+			continue
+		}
+		overhead := pack.Duration - tests
+		if overhead < 0 {
+			overhead = 0
+		}
+		for _, test := range pack.Tests {
+			items = append(items, item{pack: pIdx, test: test.Name, duration: test.Duration, overhead: overhead})
+		}
+	}
+	// Longest processing time first
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].duration != items[j].duration {
+			return items[i].duration > items[j].duration
+		}
+		if items[i].pack != items[j].pack {
+			return items[i].pack < items[j].pack
+		}
+		return items[i].test < items[j].test
+	})
+
+	plan := Plan{Shards: make([]Shard, shards)}
+	assigned := make([][]item, shards)
+	runs := make([]map[int]bool, shards) // split packages of which the shard runs a part
+	for idx := range runs {
+		runs[idx] = make(map[int]bool)
+	}
+	for _, it := range items {
+		best, bestDuration := -1, time.Duration(0)
+		for idx := range plan.Shards {
+			duration := plan.Shards[idx].Duration + it.duration
+			if it.test != "" && !runs[idx][it.pack] {
+				duration += it.overhead
+			}
+			if best < 0 || duration < bestDuration {
+				best, bestDuration = idx, duration
+			}
+		}
+		plan.Shards[best].Duration = bestDuration
+		if it.test != "" {
+			runs[best][it.pack] = true
+		}
+		assigned[best] = append(assigned[best], it)
+	}
+
+	for idx := range plan.Shards {
+		shard := &plan.Shards[idx]
+		shard.Index = idx
+		shard.Estimate = shard.Duration.String()
+		shard.Packages = []string{}
+		shard.Commands = []string{}
+		for pIdx, pack := range result.PackageResult {
+			target := pack.Target()
+			var run []string
+			whole := false
+			for _, it := range assigned[idx] {
+				switch {
+				case it.pack != pIdx:
+				case it.test == "":
+					whole = true
+				default:
+					run = append(run, it.test)
+				}
+			}
+			if len(run) == 0 {
+				if whole {
+					shard.Packages = append(shard.Packages, target)
+				}
+				continue
+			}
+			skip := otherTests(assigned, idx, pIdx)
+			switch {
+			case len(skip) == 0:
+				shard.Packages = append(shard.Packages, target)
+			case firstShard(runs, pIdx) == idx:
+				shard.Split = append(shard.Split, Split{Package: target, Skip: report.RunRegex(skip)})
+			default:
+				sort.Strings(run)
+				shard.Split = append(shard.Split, Split{Package: target, Run: report.RunRegex(run)})
+			}
+		}
+		if len(shard.Packages) > 0 {
+			shard.Commands = append(shard.Commands, "go test "+strings.Join(shard.Packages, " "))
+		}
+		for _, split := range shard.Split {
+			if split.Run != "" {
+				shard.Commands = append(shard.Commands, "go test "+split.Package+" -run "+report.ShellQuote(split.Run))
+			} else {
+				shard.Commands = append(shard.Commands, "go test "+split.Package+" -skip "+report.ShellQuote(split.Skip))
+			}
+		}
+	}
+	return plan
+}
+
+// firstShard returns the index of the first shard which runs a part of the split package.
+func firstShard(runs []map[int]bool, pack int) int {
+	for idx := range runs {
+		if runs[idx][pack] {
+			return idx
+		}
+	}
+	return -1
+}
+
+// otherTests returns the sorted tests of the package which are assigned to other shards.
+func otherTests(assigned [][]item, shard, pack int) (tests []string) {
+	for idx, items := range assigned {
+		if idx == shard {
+			continue
+		}
+		for _, it := range items {
+			if it.pack == pack && it.test != "" {
+				tests = append(tests, it.test)
+			}
+		}
+	}
+	sort.Strings(tests)
+	return tests
+}
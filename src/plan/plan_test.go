@@ -0,0 +1,114 @@
+package plan_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/plan"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPackages(t *testing.T) {
+	packages, err := plan.ReadPackages(strings.NewReader("example.com/a\n\n  example.com/b \n"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"example.com/a", "example.com/b"}, packages)
+}
+
+func TestAverage(t *testing.T) {
+	results := []report.Result{
+		{PackageResult: []report.PackageResult{
+			{Name: "a", Duration: 2 * time.Second, Tests: []report.TestResult{
+				{Name: "TestA", Duration: time.Second},
+				{Name: "TestA/sub", Duration: time.Second},
+			}},
+			{Name: "b", Duration: 4 * time.Second},
+		}},
+		{PackageResult: []report.PackageResult{
+			{Name: "a", Duration: 4 * time.Second, Tests: []report.TestResult{
+				{Name: "TestA", Duration: 3 * time.Second},
+				{Name: "TestB", Duration: time.Second},
+			}},
+		}},
+	}
+
+	average := plan.Average(results, nil)
+	assert.Equal(t, []report.PackageResult{
+		{Name: "a", Duration: 3 * time.Second, Tests: []report.TestResult{
+			{Name: "TestA", Duration: 2 * time.Second},
+			{Name: "TestB", Duration: time.Second},
+		}},
+		{Name: "b", Duration: 4 * time.Second},
+	}, average.PackageResult)
+
+	average = plan.Average(results, []string{"b", "new"})
+	assert.Equal(t, []report.PackageResult{
+		{Name: "b", Duration: 4 * time.Second},
+		{Name: "new", Duration: 3500 * time.Millisecond},
+	}, average.PackageResult)
+}
+
+func TestNew(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "a", Dir: "a", Duration: 3 * time.Second},
+		{Name: "b", Dir: "b", Duration: 2 * time.Second},
+		{Name: "c", Dir: "c", Duration: 2 * time.Second},
+		{Name: "d", Dir: "d", Duration: time.Second},
+	}}
+
+	p := plan.New(result, 2)
+	require.Len(t, p.Shards, 2)
+	assert.Equal(t, plan.Shard{Index: 0, Estimate: "4s", Duration: 4 * time.Second,
+		Packages: []string{"./a", "./d"}, Commands: []string{"go test ./a ./d"}}, p.Shards[0])
+	assert.Equal(t, plan.Shard{Index: 1, Estimate: "4s", Duration: 4 * time.Second,
+		Packages: []string{"./b", "./c"}, Commands: []string{"go test ./b ./c"}}, p.Shards[1])
+
+	p = plan.New(result, 6)
+	require.Len(t, p.Shards, 6)
+	assert.Equal(t, plan.Shard{Index: 5, Estimate: "0s", Packages: []string{}, Commands: []string{}}, p.Shards[5])
+}
+
+func TestNew_SplitPackage(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/slow", Duration: 7 * time.Second, Tests: []report.TestResult{
+			{Name: "TestA", Duration: 3 * time.Second},
+			{Name: "TestB", Duration: 3 * time.Second},
+			{Name: "TestC[1]", Duration: 500 * time.Millisecond},
+		}},
+		{Name: "example.com/fast", Duration: time.Second},
+	}}
+
+	p := plan.New(result, 2)
+	require.Len(t, p.Shards, 2)
+	// Both shards pay the 500ms of the slow package which are not spent in its tests
+	assert.Equal(t, plan.Shard{Index: 0, Estimate: "4.5s", Duration: 4500 * time.Millisecond,
+		Packages: []string{"example.com/fast"},
+		Split:    []plan.Split{{Package: "example.com/slow", Skip: "^TestB$|^TestC\\[1\\]$"}},
+		Commands: []string{"go test example.com/fast", "go test example.com/slow -skip '^TestB$|^TestC\\[1\\]$'"},
+	}, p.Shards[0])
+	assert.Equal(t, plan.Shard{Index: 1, Estimate: "4s", Duration: 4 * time.Second,
+		Packages: []string{},
+		Split:    []plan.Split{{Package: "example.com/slow", Run: "^TestB$|^TestC\\[1\\]$"}},
+		Commands: []string{"go test example.com/slow -run '^TestB$|^TestC\\[1\\]$'"},
+	}, p.Shards[1])
+}
+
+func TestNew_PackageOverhead(t *testing.T) {
+	// Most of the time of the package is spent outside of its tests, such as in the build or TestMain
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/slow", Duration: 7 * time.Second, Tests: []report.TestResult{
+			{Name: "TestA", Duration: time.Second},
+			{Name: "TestB", Duration: time.Second},
+		}},
+		{Name: "example.com/fast", Duration: time.Second},
+	}}
+
+	p := plan.New(result, 2)
+	require.Len(t, p.Shards, 2)
+	assert.Equal(t, plan.Shard{Index: 0, Estimate: "7s", Duration: 7 * time.Second,
+		Packages: []string{"example.com/slow"}, Commands: []string{"go test example.com/slow"}}, p.Shards[0])
+	assert.Equal(t, plan.Shard{Index: 1, Estimate: "1s", Duration: time.Second,
+		Packages: []string{"example.com/fast"}, Commands: []string{"go test example.com/fast"}}, p.Shards[1])
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Assertion is a failed assertion extracted from the output of a test.
+// Supported are the testify assert/require messages and go-cmp diffs.
+type Assertion struct {
+	Trace    string
+	Message  string
+	Expected string
+	Actual   string
+	Diff     string
+}
+
+var (
+	logHeaderRegex   = regexp.MustCompile(`^(\s*)([^\s:]+\.go:\d+): ?(.*)$`)
+	cmpMismatchRegex = regexp.MustCompile(`\((-want,? \+got|-got,? \+want|-expected,? \+actual|-actual,? \+expected)\):?\s*$`)
+)
+
+// ParseAssertions extracts all testify and go-cmp assertion failures from the test output.
+func ParseAssertions(output []OutputLine) (assertions []Assertion) {
+	text := strings.Builder{}
+	for _, line := range output {
+		text.WriteString(line.Text)
+	}
+	lines := strings.Split(text.String(), "\n")
+	for idx := 0; idx < len(lines); idx++ {
+		header := logHeaderRegex.FindStringSubmatch(lines[idx])
+		if header == nil {
+			continue
+		}
+		body, next := logBody(lines[idx+1:], header[1]+"    ")
+		if assertion, ok := parseTestifyAssertion(body); ok {
+			assertions = append(assertions, assertion)
+		} else if cmpMismatchRegex.MatchString(header[3]) {
+			assertions = append(assertions, Assertion{
+				Trace:   header[2],
+				Message: strings.TrimSpace(header[3]),
+				Diff:    strings.ReplaceAll(strings.Join(body, "\n"), "\u00a0", " "),
+			})
+		}
+		idx += next
+	}
+	return assertions
+}
+
+// logBody returns the continuation lines of a test log message with the indent removed
+// and the number of consumed lines.
+func logBody(lines []string, indent string) (body []string, consumed int) {
+	for _, line := range lines {
+		if !strings.HasPrefix(line, indent) {
+			break
+		}
+		body = append(body, strings.TrimPrefix(line, indent))
+		consumed++
+	}
+	return body, consumed
+}
+
+// parseTestifyAssertion parses the tab separated "Label: \tValue" table which is printed by testify.
+func parseTestifyAssertion(body []string) (assertion Assertion, ok bool) {
+	fields := make(map[string][]string)
+	label := ""
+	for _, line := range body {
+		if !strings.HasPrefix(line, "\t") {
+			break
+		}
+		cells := strings.SplitN(line[1:], "\t", 2)
+		if len(cells) != 2 {
+			break
+		}
+		if key := strings.TrimSuffix(strings.TrimSpace(cells[0]), ":"); key != "" {
+			label = key
+		}
+		if label == "" {
+			break
+		}
+		fields[label] = append(fields[label], cells[1])
+	}
+	if _, isTestify := fields["Error Trace"]; !isTestify {
+		return Assertion{}, false
+	}
+
+	assertion.Trace = strings.Join(fields["Error Trace"], "\n")
+	section := ""
+	var message, expected, actual, diff []string
+	for _, line := range fields["Error"] {
+		switch {
+		case section != "diff" && strings.HasPrefix(line, "expected:"):
+			section = "expected"
+			line = strings.TrimPrefix(line, "expected:")
+		case section != "diff" && strings.HasPrefix(line, "actual  :"):
+			section = "actual"
+			line = strings.TrimPrefix(line, "actual  :")
+		case section != "diff" && strings.TrimSpace(line) == "Diff:":
+			section = "diff"
+			continue
+		}
+		switch section {
+		case "expected":
+			expected = append(expected, line)
+		case "actual":
+			actual = append(actual, line)
+		case "diff":
+			diff = append(diff, line)
+		default:
+			message = append(message, line)
+		}
+	}
+	message = append(message, fields["Messages"]...)
+
+	assertion.Message = joinTrimmed(message)
+	assertion.Expected = joinTrimmed(expected)
+	assertion.Actual = joinTrimmed(actual)
+	assertion.Diff = strings.TrimRight(strings.Join(diff, "\n"), "\n ")
+	return assertion, true
+}
+
+func joinTrimmed(lines []string) string {
+	trimmed := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed = append(trimmed, strings.TrimRight(line, " "))
+	}
+	return strings.TrimSpace(strings.Join(trimmed, "\n"))
+}
+
+// UnifiedDiff returns the diff of the assertion. If the assertion did not print
+// a diff, one is created from the expected and actual value.
+func (a Assertion) UnifiedDiff() string {
+	if a.Diff != "" || (a.Expected == "" && a.Actual == "") {
+		return a.Diff
+	}
+	diff := strings.Builder{}
+	diff.WriteString("--- Expected\n+++ Actual\n")
+	for _, line := range strings.Split(a.Expected, "\n") {
+		diff.WriteString("-" + line + "\n")
+	}
+	for _, line := range strings.Split(a.Actual, "\n") {
+		diff.WriteString("+" + line + "\n")
+	}
+	return strings.TrimSuffix(diff.String(), "\n")
+}
@@ -0,0 +1,94 @@
+package report_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func outputLines(text string) (lines []report.OutputLine) {
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lines = append(lines, report.OutputLine{Text: line})
+	}
+	return lines
+}
+
+func TestParseAssertions(t *testing.T) {
+	var suite = []struct {
+		output     string
+		assertions []report.Assertion
+	}{
+		{"", nil},
+		{"=== RUN   TestFoo\n--- PASS: TestFoo (0.00s)\n", nil},
+		{"    foo_test.go:12: just a log line\n", nil},
+		{
+			"=== RUN   TestRecurseReturnErr\n" +
+				"    tree_test.go:101: \n" +
+				"        \tError Trace:\ttree_test.go:101\n" +
+				"        \tError:      \tExpected value not to be nil.\n" +
+				"        \tTest:       \tTestRecurseReturnErr\n" +
+				"--- FAIL: TestRecurseReturnErr (0.00s)\n",
+			[]report.Assertion{{Trace: "tree_test.go:101", Message: "Expected value not to be nil."}},
+		},
+		{
+			"    foo_test.go:7: \n" +
+				"        \tError Trace:\t/src/foo_test.go:7\n" +
+				"        \tError:      \tNot equal: \n" +
+				"        \t            \texpected: \"a\"\n" +
+				"        \t            \tactual  : \"b\"\n" +
+				"        \t            \t\n" +
+				"        \t            \tDiff:\n" +
+				"        \t            \t--- Expected\n" +
+				"        \t            \t+++ Actual\n" +
+				"        \t            \t@@ -1 +1 @@\n" +
+				"        \t            \t-a\n" +
+				"        \t            \t+b\n" +
+				"        \tTest:       \tTestFoo\n" +
+				"        \tMessages:   \tcustom message\n",
+			[]report.Assertion{{
+				Trace:    "/src/foo_test.go:7",
+				Message:  "Not equal:\ncustom message",
+				Expected: `"a"`,
+				Actual:   `"b"`,
+				Diff:     "--- Expected\n+++ Actual\n@@ -1 +1 @@\n-a\n+b",
+			}},
+		},
+		{
+			"        foo_test.go:20: Foo() mismatch (-want +got):\n" +
+				"              string(\n" +
+				"            - \t\"a\",\n" +
+				"            + \t\"b\",\n" +
+				"              )\n" +
+				"    --- FAIL: TestFoo/sub (0.00s)\n",
+			[]report.Assertion{{
+				Trace:   "foo_test.go:20",
+				Message: "Foo() mismatch (-want +got):",
+				Diff:    "  string(\n- \t\"a\",\n+ \t\"b\",\n  )",
+			}},
+		},
+	}
+	for i, s := range suite {
+		t.Run(fmt.Sprintf("(%d)", i), func(t *testing.T) {
+			assert.Equal(t, s.assertions, report.ParseAssertions(outputLines(s.output)))
+		})
+	}
+}
+
+func TestAssertionUnifiedDiff(t *testing.T) {
+	var suite = []struct {
+		assertion report.Assertion
+		diff      string
+	}{
+		{report.Assertion{}, ""},
+		{report.Assertion{Diff: "-a\n+b", Expected: "x", Actual: "y"}, "-a\n+b"},
+		{report.Assertion{Expected: "1", Actual: "2"}, "--- Expected\n+++ Actual\n-1\n+2"},
+	}
+	for _, s := range suite {
+		t.Run(s.diff, func(t *testing.T) {
+			assert.Equal(t, s.diff, s.assertion.UnifiedDiff())
+		})
+	}
+}
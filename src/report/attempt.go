@@ -0,0 +1,46 @@
+package report
+
+import "time"
+
+// Attempt is an additional run of a failed test.
+type Attempt struct {
+	TestResult FinalTestStatus
+	Duration   time.Duration
+	Started    time.Time // zero if the test did not run again
+	Output     []OutputLine
+}
+
+// LastStatus returns the status of the last attempt or the status of the test if it was not rerun.
+func (t TestResult) LastStatus() FinalTestStatus {
+	if len(t.Attempts) == 0 {
+		return t.TestResult
+	}
+	return t.Attempts[len(t.Attempts)-1].TestResult
+}
+
+// Flaky is true if the test failed, but passed on a rerun.
+func (t TestResult) Flaky() bool {
+	return t.TestResult == FTSFail && t.LastStatus() == FTSPass
+}
+
+// PersistentFailure is true if the test failed and is neither flaky nor quarantined.
+func (t TestResult) PersistentFailure() bool {
+	return t.TestResult == FTSFail && !t.Flaky() && t.Quarantine == nil
+}
+
+// Classification returns "flaky" for tests which failed but passed on a rerun and
+// otherwise "passed", "failed", "skipped" or "incomplete".
+func (t TestResult) Classification() string {
+	switch {
+	case t.Flaky():
+		return "flaky"
+	case t.TestResult == FTSPass:
+		return "passed"
+	case t.TestResult == FTSFail:
+		return "failed"
+	case t.TestResult == FTPSSkip:
+		return "skipped"
+	default:
+		return t.TestResult.String()
+	}
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Budget is the maximum duration of matching tests or packages.
+type Budget struct {
+	// Package glob or regular expression if Regex is set. Matches all packages if empty.
+	Package string `json:"package,omitempty"`
+	// Test glob or regular expression if Regex is set. If empty the budget applies to the package duration.
+	Test  string `json:"test,omitempty"`
+	Regex bool   `json:"regex,omitempty"`
+	// Max duration such as "1.5s" or "2m".
+	Max string `json:"max"`
+
+	packageRegex *regexp.Regexp
+	testRegex    *regexp.Regexp
+	max          time.Duration
+}
+
+func (b Budget) matchesPackage(pack PackageName) bool {
+	return b.packageRegex == nil || b.packageRegex.MatchString(string(pack))
+}
+
+// BudgetViolation is a test or package which took longer than its budget.
+type BudgetViolation struct {
+	Package  PackageName
+	Test     string // empty if the package exceeded its budget
+	Duration time.Duration
+	Budget   time.Duration
+}
+
+// Exceeded is the time by which the budget was exceeded.
+func (v BudgetViolation) Exceeded() time.Duration {
+	return v.Duration - v.Budget
+}
+
+// Budgets is an ordered list of budgets. The first matching budget applies.
+type Budgets []Budget
+
+func LoadBudgets(pathToFile string) (budgets Budgets, err error) {
+	content, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBudgets(content)
+}
+
+func ParseBudgets(content []byte) (budgets Budgets, err error) {
+	if err := json.Unmarshal(content, &budgets); err != nil {
+		return nil, fmt.Errorf("invalid budgets. %s", err)
+	}
+	for idx := range budgets {
+		budget := &budgets[idx]
+		if budget.max, err = time.ParseDuration(budget.Max); err != nil || budget.max <= 0 {
+			return nil, fmt.Errorf("budget %d must have a positive max duration such as 1s, got %q", idx, budget.Max)
+		}
+		if budget.Package != "" {
+			if budget.packageRegex, err = compilePattern(budget.Package, budget.Regex); err != nil {
+				return nil, fmt.Errorf("invalid package pattern of budget %d. %s", idx, err)
+			}
+		}
+		if budget.Test != "" {
+			if budget.testRegex, err = compilePattern(budget.Test, budget.Regex); err != nil {
+				return nil, fmt.Errorf("invalid test pattern of budget %d. %s", idx, err)
+			}
+		}
+	}
+	return budgets, nil
+}
+
+// Apply sets the budget of all matching packages and tests and collects the violations
+// ordered by the exceeded time. Skipped and incomplete tests are ignored.
+func (b Budgets) Apply(result *Result) {
+	result.BudgetViolations = nil
+	for pIdx := range result.PackageResult {
+		pack := &result.PackageResult[pIdx]
+		pack.Budget = 0
+		for _, budget := range b {
+			if budget.testRegex == nil && budget.matchesPackage(pack.Name) {
+				pack.Budget = budget.max
+				break
+			}
+		}
+		if pack.OverBudget() {
+			result.BudgetViolations = append(result.BudgetViolations, BudgetViolation{
+				Package: pack.Name, Duration: pack.Duration, Budget: pack.Budget,
+			})
+		}
+		for tIdx := range pack.Tests {
+			test := &pack.Tests[tIdx]
+			test.Budget = 0
+			if test.TestResult != FTSPass && test.TestResult != FTSFail {
+				continue
+			}
+			for _, budget := range b {
+				if budget.testRegex != nil && budget.matchesPackage(pack.Name) && budget.testRegex.MatchString(test.Name) {
+					test.Budget = budget.max
+					break
+				}
+			}
+			if test.OverBudget() {
+				result.BudgetViolations = append(result.BudgetViolations, BudgetViolation{
+					Package: pack.Name, Test: test.Name, Duration: test.Duration, Budget: test.Budget,
+				})
+			}
+		}
+	}
+	sort.SliceStable(result.BudgetViolations, func(i, j int) bool {
+		return result.BudgetViolations[i].Exceeded() > result.BudgetViolations[j].Exceeded()
+	})
+}
@@ -0,0 +1,70 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBudgets(t *testing.T) {
+	var suite = []struct {
+		in    string
+		isErr bool
+	}{
+		{`[]`, false},
+		{`[{"max":"1s"}]`, false},
+		{`[{"package":"foo/*","test":"Test?","max":"1m"}]`, false},
+		{`[{"package":"^foo/.*$","test":"^Test","regex":true,"max":"1ms"}]`, false},
+
+		{``, true},
+		{`[{}]`, true},
+		{`[{"max":"1"}]`, true},
+		{`[{"max":"-1s"}]`, true},
+		{`[{"package":"(","regex":true,"max":"1s"}]`, true},
+		{`[{"test":"(","regex":true,"max":"1s"}]`, true},
+	}
+	for _, s := range suite {
+		t.Run(s.in, func(t *testing.T) {
+			_, err := report.ParseBudgets([]byte(s.in))
+			assert.Equal(t, s.isErr, err != nil)
+		})
+	}
+}
+
+func TestBudgetsApply(t *testing.T) {
+	budgets, err := report.ParseBudgets([]byte(`[
+		{"package":"foo/*","test":"TestSlow*","max":"2s"},
+		{"package":"foo/*","max":"10s"},
+		{"test":"^Test","regex":true,"max":"1s"}
+	]`))
+	require.Nil(t, err)
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "foo/a", Duration: time.Second * 11, Tests: []report.TestResult{
+			{Name: "TestSlow", Duration: time.Second * 2, TestResult: report.FTSPass},
+			{Name: "TestSlow/sub", Duration: time.Second * 3, TestResult: report.FTSFail},
+			{Name: "TestFast", Duration: time.Second * 2, TestResult: report.FTSPass},
+			{Name: "TestSkipped", Duration: time.Second * 2, TestResult: report.FTPSSkip},
+		}},
+		{Name: "bar", Duration: time.Hour, Tests: []report.TestResult{
+			{Name: "TestBar", Duration: time.Second * 5, TestResult: report.FTSFail},
+		}},
+	}}
+
+	budgets.Apply(&result)
+
+	assert.Equal(t, []report.BudgetViolation{
+		{Package: "bar", Test: "TestBar", Duration: time.Second * 5, Budget: time.Second},
+		{Package: "foo/a", Duration: time.Second * 11, Budget: time.Second * 10},
+		{Package: "foo/a", Test: "TestSlow/sub", Duration: time.Second * 3, Budget: time.Second * 2},
+		{Package: "foo/a", Test: "TestFast", Duration: time.Second * 2, Budget: time.Second},
+	}, result.BudgetViolations)
+	assert.True(t, result.PackageResult[0].OverBudget())
+	assert.False(t, result.PackageResult[0].Tests[0].OverBudget())
+	assert.Equal(t, time.Second*2, result.PackageResult[0].Tests[0].Budget)
+	assert.Equal(t, time.Duration(0), result.PackageResult[0].Tests[3].Budget)
+	assert.False(t, result.PackageResult[1].OverBudget())
+	assert.Equal(t, time.Duration(0), result.PackageResult[1].Budget)
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// coverageRegex matches the coverage which go test -cover prints for every package.
+var coverageRegex = regexp.MustCompile(`coverage: (\d+(?:\.\d+)?)% of statements`)
+
+// parseCoverage returns the last coverage in the output or nil if the output contains no coverage.
+func parseCoverage(output []OutputLine) *float64 {
+	for idx := len(output) - 1; idx >= 0; idx-- {
+		if match := coverageRegex.FindStringSubmatch(output[idx].Text); match != nil {
+			if coverage, err := strconv.ParseFloat(match[1], 64); err == nil {
+				return &coverage
+			}
+		}
+	}
+	return nil
+}
+
+// Statements are the number of statements and covered statements of a package in a cover profile.
+type Statements struct {
+	Total   int
+	Covered int
+}
+
+// CoverProfile are the statements of the packages of a profile written by go test -coverprofile.
+type CoverProfile map[PackageName]Statements
+
+// Coverage returns the percentage of covered statements of all packages for which selects is true, weighted by
+// their statements. Nil if the packages have no statements.
+func (p CoverProfile) Coverage(selects func(pack PackageName) bool) *float64 {
+	total, covered := 0, 0
+	for pack, statements := range p {
+		if selects(pack) {
+			total += statements.Total
+			covered += statements.Covered
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	coverage := float64(covered) * 100 / float64(total)
+	return &coverage
+}
+
+// SetCoverProfile sets the cover profile and the total coverage of all its packages.
+func (r *Result) SetCoverProfile(profile CoverProfile) {
+	r.CoverProfile = profile
+	r.Coverage = profile.Coverage(func(PackageName) bool { return true })
+}
+
+// ParseCoverProfile parses a profile written by go test -coverprofile.
+// Blocks which are listed multiple times, such as with -coverpkg, are covered if one of them is covered.
+func ParseCoverProfile(in io.Reader) (CoverProfile, error) {
+	type block struct {
+		statements int
+		covered    bool
+	}
+	blocks := make(map[string]*block)
+	scanner := bufio.NewScanner(in)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// Lines have the form <file>:<start line>.<start column>,<end line>.<end column> <statements> <count>
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !strings.Contains(fields[0], ":") {
+			return nil, fmt.Errorf("invalid cover profile line %d: %s", lineNumber, line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of statements in cover profile line %d: %s", lineNumber, line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid count in cover profile line %d: %s", lineNumber, line)
+		}
+		b, ok := blocks[fields[0]]
+		if !ok {
+			b = &block{statements: statements}
+			blocks[fields[0]] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	profile := make(CoverProfile)
+	total := 0
+	for key, b := range blocks {
+		file := key[:strings.LastIndex(key, ":")]
+		pack := PackageName(path.Dir(file))
+		statements := profile[pack]
+		statements.Total += b.statements
+		if b.covered {
+			statements.Covered += b.statements
+		}
+		profile[pack] = statements
+		total += b.statements
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("cover profile contains no statements")
+	}
+	return profile, nil
+}
@@ -0,0 +1,99 @@
+package report_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTestJson_Coverage(t *testing.T) {
+	res, err := report.ParseTestJson(strings.NewReader(`{"Action":"start","Package":"example.com/a"}
+{"Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Action":"pass","Package":"example.com/a","Test":"TestA","Elapsed":0}
+{"Action":"output","Package":"example.com/a","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/a","Output":"coverage: 80.0% of statements\n"}
+{"Action":"output","Package":"example.com/a","Output":"ok  \texample.com/a\t0.1s\tcoverage: 80.0% of statements\n"}
+{"Action":"pass","Package":"example.com/a","Elapsed":0.1}
+{"Action":"start","Package":"example.com/b"}
+{"Action":"run","Package":"example.com/b","Test":"TestB"}
+{"Action":"pass","Package":"example.com/b","Test":"TestB","Elapsed":0}
+{"Action":"output","Package":"example.com/b","Output":"ok  \texample.com/b\t0.1s\tcoverage: 50.5% of statements\n"}
+{"Action":"pass","Package":"example.com/b","Elapsed":0.1}
+{"Action":"start","Package":"example.com/c"}
+{"Action":"run","Package":"example.com/c","Test":"TestC"}
+{"Action":"pass","Package":"example.com/c","Test":"TestC","Elapsed":0}
+{"Action":"pass","Package":"example.com/c","Elapsed":0.1}
+`))
+	require.Nil(t, err)
+
+	coverage := make(map[report.PackageName]*float64)
+	for _, pack := range res.PackageResult {
+		coverage[pack.Name] = pack.Coverage
+	}
+	require.NotNil(t, coverage["example.com/a"])
+	assert.Equal(t, 80.0, *coverage["example.com/a"])
+	require.NotNil(t, coverage["example.com/b"])
+	assert.Equal(t, 50.5, *coverage["example.com/b"])
+	assert.Nil(t, coverage["example.com/c"])
+	// The package coverages cannot be weighted by their statements without cover profile
+	assert.Nil(t, res.Coverage)
+}
+
+func TestParseTestJson_NoCoverage(t *testing.T) {
+	res, err := report.ParseTestJson(strings.NewReader(`{"Action":"pass","Package":"example.com/a","Elapsed":0.1}`))
+	require.Nil(t, err)
+	assert.Nil(t, res.Coverage)
+}
+
+func TestParseCoverProfile(t *testing.T) {
+	var suite = []struct {
+		profile  string
+		coverage float64
+		isErr    bool
+	}{
+		{"mode: set\nexample.com/a/a.go:3.14,5.2 2 1\nexample.com/a/a.go:7.14,9.2 2 0\n", 50, false},
+		{"mode: count\nexample.com/a/a.go:3.14,5.2 3 4\nexample.com/a/a.go:7.14,9.2 1 0\n", 75, false},
+		// Blocks of -coverpkg profiles are listed for every tested package
+		{"mode: set\nb/a.go:3.14,5.2 2 0\nb/a.go:7.14,9.2 2 0\nb/a.go:3.14,5.2 2 1\n", 50, false},
+		// Packages are weighted by their statements
+		{"mode: set\nexample.com/a/a.go:3.14,5.2 1 1\nexample.com/b/b.go:3.14,5.2 3 0\n", 25, false},
+		{"mode: set\n", 0, true},
+		{"mode: set\na.go:3.14,5.2 2\n", 0, true},
+		{"mode: set\na.go:3.14,5.2 x 1\n", 0, true},
+		{"mode: set\na.go 2 1\n", 0, true},
+		{"mode: set\na.go:3.14,5.2 2 x\n", 0, true},
+	}
+	for _, s := range suite {
+		t.Run(s.profile, func(t *testing.T) {
+			profile, err := report.ParseCoverProfile(strings.NewReader(s.profile))
+			if s.isErr {
+				assert.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+				var result report.Result
+				result.SetCoverProfile(profile)
+				require.NotNil(t, result.Coverage)
+				assert.Equal(t, s.coverage, *result.Coverage)
+			}
+		})
+	}
+}
+
+func TestFilterApply_Coverage(t *testing.T) {
+	profile, err := report.ParseCoverProfile(strings.NewReader("mode: set\nexample.com/a/a.go:3.14,5.2 1 1\nexample.com/b/b.go:3.14,5.2 3 0\n"))
+	require.Nil(t, err)
+	result := report.Result{PackageResult: []report.PackageResult{{Name: "example.com/a"}, {Name: "example.com/b"}}}
+	result.SetCoverProfile(profile)
+
+	filter := report.Filter{}
+	require.Nil(t, filter.AddPattern("package:example.com/a", true))
+	filtered := filter.Apply(result)
+
+	require.NotNil(t, filtered.Coverage)
+	assert.Equal(t, 100.0, *filtered.Coverage)
+	assert.Equal(t, 25.0, *result.Coverage)
+	assert.Equal(t, 25.0, *report.Filter{}.Apply(result).Coverage)
+}
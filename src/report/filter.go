@@ -0,0 +1,224 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter selects the packages and tests of a result.
+// The zero value selects everything.
+type Filter struct {
+	IncludePackages []*regexp.Regexp
+	ExcludePackages []*regexp.Regexp
+	IncludeTests    []*regexp.Regexp
+	ExcludeTests    []*regexp.Regexp
+	IncludeOwners   []*regexp.Regexp
+	ExcludeOwners   []*regexp.Regexp
+	Statuses        []FinalTestStatus // selects all states if empty
+}
+
+// AddPattern adds an include or exclude pattern of the form <kind>:<pattern>.
+// The kind is one of package, package-regex, test, test-regex or owner. Package, test and owner patterns are globs.
+func (f *Filter) AddPattern(pattern string, include bool) error {
+	kind, expr, ok := strings.Cut(pattern, ":")
+	if !ok {
+		return fmt.Errorf("filter %s must have the form <kind>:<pattern>", pattern)
+	}
+	regex, err := compilePattern(expr, strings.HasSuffix(kind, "-regex"))
+	if err != nil {
+		return fmt.Errorf("invalid filter %s. %s", pattern, err)
+	}
+	var target *[]*regexp.Regexp
+	switch kind {
+	case "package", "package-regex":
+		target = &f.ExcludePackages
+		if include {
+			target = &f.IncludePackages
+		}
+	case "test", "test-regex":
+		target = &f.ExcludeTests
+		if include {
+			target = &f.IncludeTests
+		}
+	case "owner":
+		target = &f.ExcludeOwners
+		if include {
+			target = &f.IncludeOwners
+		}
+	default:
+		return fmt.Errorf("unknown filter kind %s. Expected package, package-regex, test, test-regex or owner", kind)
+	}
+	*target = append(*target, regex)
+	return nil
+}
+
+// AddStatuses adds a comma separated list of test states such as "fail,incomplete".
+func (f *Filter) AddStatuses(statuses string) error {
+	for _, name := range strings.Split(statuses, ",") {
+		if name == "" {
+			continue
+		}
+		status, ok := FinalTestStatusFromString(name)
+		if !ok {
+			return fmt.Errorf("unknown test status %s", name)
+		}
+		f.Statuses = append(f.Statuses, status)
+	}
+	return nil
+}
+
+func (f Filter) filtersTests() bool {
+	return len(f.IncludeTests) > 0 || len(f.ExcludeTests) > 0 || len(f.Statuses) > 0
+}
+
+func (f Filter) empty() bool {
+	return len(f.IncludePackages) == 0 && len(f.ExcludePackages) == 0 && len(f.IncludeOwners) == 0 && len(f.ExcludeOwners) == 0 && !f.filtersTests()
+}
+
+// keepsBuildFailures is true if packages which failed without tests, such as on build errors, are selected
+// although tests are filtered. This is the case if failures are selected and no test is explicitly included.
+func (f Filter) keepsBuildFailures() bool {
+	if len(f.IncludeTests) > 0 {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, status := range f.Statuses {
+		if status == FTSFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) selectsPackage(pack PackageResult) bool {
+	if !selects(string(pack.Name), f.IncludePackages, f.ExcludePackages) {
+		return false
+	}
+	if len(f.IncludeOwners) == 0 && len(f.ExcludeOwners) == 0 {
+		return true
+	}
+	selected := len(f.IncludeOwners) == 0
+	for _, owner := range pack.Owners {
+		if !selects(owner, nil, f.ExcludeOwners) {
+			return false
+		}
+		if selects(owner, f.IncludeOwners, nil) {
+			selected = true
+		}
+	}
+	return selected
+}
+
+func (f Filter) selectsTest(test TestResult) bool {
+	if !selects(test.Name, f.IncludeTests, f.ExcludeTests) {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, status := range f.Statuses {
+		if test.TestResult == status {
+			return true
+		}
+	}
+	return false
+}
+
+func selects(name string, include, exclude []*regexp.Regexp) bool {
+	for _, regex := range exclude {
+		if regex.MatchString(name) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, regex := range include {
+		if regex.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns a copy of the result which only contains the selected packages and tests.
+// The totals are recomputed for the selection. If tests are filtered, packages without any
+// selected test are removed. Failed packages without tests are kept if failures are selected.
+func (f Filter) Apply(result Result) Result {
+	filtered := result
+	filtered.PackageResult = make([]PackageResult, 0, len(result.PackageResult))
+	for _, pack := range result.PackageResult {
+		if !f.selectsPackage(pack) {
+			continue
+		}
+		tests := make([]TestResult, 0, len(pack.Tests))
+		for _, test := range pack.Tests {
+			if f.selectsTest(test) {
+				tests = append(tests, test)
+			}
+		}
+		buildFailure := len(pack.Tests) == 0 && pack.PackageResult == FTSFail
+		if len(tests) == 0 && f.filtersTests() && !(buildFailure && f.keepsBuildFailures()) {
+			continue
+		}
+		pack.Tests = tests
+		filtered.PackageResult = append(filtered.PackageResult, pack)
+	}
+
+	filtered.BudgetViolations = nil
+	for _, violation := range result.BudgetViolations {
+		if filtered.contains(violation.Package, violation.Test) {
+			filtered.BudgetViolations = append(filtered.BudgetViolations, violation)
+		}
+	}
+	filtered.Quarantine = nil
+	for _, status := range result.Quarantine {
+		if f.selectsQuarantine(filtered, status) {
+			filtered.Quarantine = append(filtered.Quarantine, status)
+		}
+	}
+	if filtered.CoverProfile != nil && !f.empty() {
+		filtered.Coverage = filtered.CoverProfile.Coverage(func(pack PackageName) bool { return filtered.contains(pack, "") })
+	}
+	filtered.UpdateTotals()
+	return filtered
+}
+
+// selectsQuarantine is true if the entry matches a selected test. Entries which matched no test at all are
+// only selected without test filters if their package is selected.
+func (f Filter) selectsQuarantine(filtered Result, status QuarantineStatus) bool {
+	if f.empty() {
+		return true
+	}
+	for _, pack := range filtered.PackageResult {
+		for _, test := range pack.Tests {
+			if status.Entry.matches(pack.Name, test.Name) {
+				return true
+			}
+		}
+	}
+	if status.Matched > 0 || status.Entry.Package == "" || status.Entry.Regex || f.filtersTests() {
+		return false
+	}
+	return f.selectsPackage(PackageResult{Name: PackageName(status.Entry.Package)})
+}
+
+func (r Result) contains(pack PackageName, test string) bool {
+	for _, p := range r.PackageResult {
+		if p.Name != pack {
+			continue
+		}
+		if test == "" {
+			return true
+		}
+		for _, t := range p.Tests {
+			if t.Name == test {
+				return true
+			}
+		}
+	}
+	return false
+}
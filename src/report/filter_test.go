@@ -0,0 +1,153 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterAddPattern(t *testing.T) {
+	var suite = []struct {
+		pattern string
+		isErr   bool
+	}{
+		{"package:*/api/*", false},
+		{"package-regex:^api/", false},
+		{"test:Test?", false},
+		{"test-regex:^Test(A|B)$", false},
+
+		{"foo", true},
+		{"foo:bar", true},
+		{"test-regex:(", true},
+	}
+	for _, s := range suite {
+		t.Run(s.pattern, func(t *testing.T) {
+			var filter report.Filter
+			assert.Equal(t, s.isErr, filter.AddPattern(s.pattern, true) != nil)
+		})
+	}
+}
+
+func TestFilterAddStatuses(t *testing.T) {
+	var filter report.Filter
+	require.Nil(t, filter.AddStatuses("fail,,incomplete"))
+	assert.Equal(t, []report.FinalTestStatus{report.FTSFail, report.FTSIncomplete}, filter.Statuses)
+	assert.NotNil(t, filter.AddStatuses("failed"))
+}
+
+func TestFilterApply(t *testing.T) {
+	result := report.Result{
+		Vars: map[string]string{"Title": "foo"},
+		PackageResult: []report.PackageResult{
+			{Name: "repo/api/users", Duration: time.Second * 2, PackageResult: report.FTSFail, Tests: []report.TestResult{
+				{Name: "TestGet", TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+				{Name: "TestGet/sub", TestResult: report.FTSPass},
+				{Name: "TestPut", TestResult: report.FTPSSkip},
+			}},
+			{Name: "repo/api/build", PackageResult: report.FTSFail},
+			{Name: "repo/storage", Duration: time.Second, PackageResult: report.FTSPass, Tests: []report.TestResult{
+				{Name: "TestRead", TestResult: report.FTSPass},
+			}},
+		},
+		BudgetViolations: []report.BudgetViolation{
+			{Package: "repo/api/users", Test: "TestGet/sub"},
+			{Package: "repo/storage", Test: "TestRead"},
+		},
+	}
+	result.UpdateTotals()
+	assert.Equal(t, uint(4), result.Tests)
+	assert.Equal(t, time.Second*3, result.Duration)
+
+	var suite = []struct {
+		name     string
+		include  []string
+		exclude  []string
+		statuses string
+		packages map[report.PackageName][]string
+		totals   [5]uint // tests, passed, failed, skipped, quarantined
+	}{
+		{"all", nil, nil, "",
+			map[report.PackageName][]string{"repo/api/users": {"TestGet", "TestGet/sub", "TestPut"}, "repo/api/build": {}, "repo/storage": {"TestRead"}},
+			[5]uint{4, 2, 1, 1, 1}},
+		{"package", []string{"package:repo/api/*"}, nil, "",
+			map[report.PackageName][]string{"repo/api/users": {"TestGet", "TestGet/sub", "TestPut"}, "repo/api/build": {}},
+			[5]uint{3, 1, 1, 1, 1}},
+		{"exclude package", nil, []string{"package-regex:/api/"}, "",
+			map[report.PackageName][]string{"repo/storage": {"TestRead"}},
+			[5]uint{1, 1, 0, 0, 0}},
+		{"tests", []string{"test:TestGet*"}, []string{"test:*/sub"}, "",
+			map[report.PackageName][]string{"repo/api/users": {"TestGet"}},
+			[5]uint{1, 0, 1, 0, 1}},
+		{"exclude tests", nil, []string{"test:*/sub"}, "",
+			map[report.PackageName][]string{"repo/api/users": {"TestGet", "TestPut"}, "repo/api/build": {}, "repo/storage": {"TestRead"}},
+			[5]uint{3, 1, 1, 1, 1}},
+		{"failures", nil, nil, "fail",
+			map[report.PackageName][]string{"repo/api/users": {"TestGet"}, "repo/api/build": {}},
+			[5]uint{1, 0, 1, 0, 1}},
+		{"status", nil, nil, "pass,skip",
+			map[report.PackageName][]string{"repo/api/users": {"TestGet/sub", "TestPut"}, "repo/storage": {"TestRead"}},
+			[5]uint{3, 2, 0, 1, 0}},
+	}
+	for _, s := range suite {
+		t.Run(s.name, func(t *testing.T) {
+			var filter report.Filter
+			for _, pattern := range s.include {
+				require.Nil(t, filter.AddPattern(pattern, true))
+			}
+			for _, pattern := range s.exclude {
+				require.Nil(t, filter.AddPattern(pattern, false))
+			}
+			require.Nil(t, filter.AddStatuses(s.statuses))
+
+			filtered := filter.Apply(result)
+
+			packages := map[report.PackageName][]string{}
+			for _, pack := range filtered.PackageResult {
+				packages[pack.Name] = []string{}
+				for _, test := range pack.Tests {
+					packages[pack.Name] = append(packages[pack.Name], test.Name)
+				}
+			}
+			assert.Equal(t, s.packages, packages)
+			assert.Equal(t, s.totals, [5]uint{filtered.Tests, filtered.Passed, filtered.Failed, filtered.Skipped, filtered.Quarantined})
+			assert.Equal(t, result.Vars, filtered.Vars)
+			for _, violation := range filtered.BudgetViolations {
+				assert.Contains(t, packages[violation.Package], violation.Test)
+			}
+		})
+	}
+	assert.Len(t, result.PackageResult[0].Tests, 3)
+}
+
+func TestFilterApply_Quarantine(t *testing.T) {
+	result := report.Result{
+		PackageResult: []report.PackageResult{
+			{Name: "repo/api", PackageResult: report.FTSPass, Tests: []report.TestResult{{Name: "TestGet", TestResult: report.FTSPass}}},
+			{Name: "repo/storage", PackageResult: report.FTSPass, Tests: []report.TestResult{{Name: "TestRead", TestResult: report.FTSPass}}},
+		},
+		Quarantine: []report.QuarantineStatus{
+			{Entry: report.QuarantineEntry{Test: "TestGet"}, Matched: 1},
+			{Entry: report.QuarantineEntry{Package: "repo/storage", Test: "TestRead"}, Matched: 1},
+			{Entry: report.QuarantineEntry{Package: "repo/api", Test: "TestGone"}},
+			{Entry: report.QuarantineEntry{Package: "repo/storage", Test: "TestGone"}},
+		},
+	}
+	entries := func(r report.Result) (names []string) {
+		for _, status := range r.Quarantine {
+			names = append(names, status.Entry.String())
+		}
+		return names
+	}
+
+	var filter report.Filter
+	assert.Equal(t, entries(result), entries(filter.Apply(result)))
+
+	require.Nil(t, filter.AddPattern("package:repo/api", true))
+	assert.Equal(t, []string{"TestGet", "repo/api TestGone"}, entries(filter.Apply(result)))
+
+	require.Nil(t, filter.AddStatuses("pass"))
+	assert.Equal(t, []string{"TestGet"}, entries(filter.Apply(result)))
+}
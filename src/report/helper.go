@@ -2,6 +2,7 @@ package report
 
 import (
 	"html"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -37,3 +38,23 @@ func EscapeMarkdown(input string) (escapedMarkdown string) {
 func EscapeHtml(input string) (escapedHtml string) {
 	return html.EscapeString(input)
 }
+
+func CodeBlock(language, input string) (codeBlock string) {
+	fence := "```"
+	for strings.Contains(input, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + strings.TrimSuffix(input, "\n") + "\n" + fence
+}
+
+// compilePattern compiles a regular expression or a glob pattern in which '*' matches any
+// sequence of characters and '?' a single character.
+func compilePattern(pattern string, isRegex bool) (*regexp.Regexp, error) {
+	if isRegex {
+		return regexp.Compile(pattern)
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.Compile("^" + expr + "$")
+}
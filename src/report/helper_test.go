@@ -76,3 +76,20 @@ func TestEscapeHtml(t *testing.T) {
 		})
 	}
 }
+
+func TestCodeBlock(t *testing.T) {
+	var suite = []struct {
+		language string
+		in       string
+		out      string
+	}{
+		{"", "foo", "```\nfoo\n```"},
+		{"diff", "-a\n+b\n", "```diff\n-a\n+b\n```"},
+		{"go", "x := \"```\"", "````go\nx := \"```\"\n````"},
+	}
+	for _, s := range suite {
+		t.Run(s.out, func(t *testing.T) {
+			assert.Equal(t, s.out, report.CodeBlock(s.language, s.in))
+		})
+	}
+}
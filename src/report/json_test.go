@@ -28,3 +28,23 @@ func TestUnmarshalTestAction(t *testing.T) {
 		Output:  "    --- PASS: TestIsMatch/___#04 (0.00s)\n",
 	})
 }
+
+func TestTestActionString(t *testing.T) {
+	var suite = []struct {
+		action report.TestAction
+		str    string
+	}{
+		{report.TAUnknown, "unknown"},
+		{report.TARun, "run"},
+		{report.TASkip, "skip"},
+		{report.TAStart, "start"},
+	}
+	for _, s := range suite {
+		t.Run(s.str, func(t *testing.T) {
+			assert.Equal(t, s.str, s.action.String())
+			if s.action != report.TAUnknown {
+				assert.Equal(t, s.action, report.TestActionFromString(s.str))
+			}
+		})
+	}
+}
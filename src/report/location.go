@@ -0,0 +1,86 @@
+package report
+
+import (
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Location is a position in a source file.
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return l.File + ":" + strconv.Itoa(l.Line)
+}
+
+// parseLocation parses locations of the form "file.go:12".
+func parseLocation(s string) (location Location, ok bool) {
+	idx := strings.LastIndex(s, ":")
+	if idx <= 0 || !strings.HasSuffix(s[:idx], ".go") {
+		return Location{}, false
+	}
+	line, err := strconv.Atoi(s[idx+1:])
+	if err != nil || line <= 0 {
+		return Location{}, false
+	}
+	return Location{File: s[:idx], Line: line}, true
+}
+
+// Locations returns the distinct source locations which were logged by the test.
+// The locations of failed assertions come first. The file names are often only
+// base names without directory.
+func (t TestResult) Locations() (locations []Location) {
+	seen := make(map[Location]bool)
+	add := func(location Location) {
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	for _, assertion := range t.Assertions {
+		for _, trace := range strings.Split(assertion.Trace, "\n") {
+			if location, ok := parseLocation(strings.TrimSpace(trace)); ok {
+				add(location)
+			}
+		}
+	}
+	for _, line := range t.Output {
+		if header := logHeaderRegex.FindStringSubmatch(strings.TrimSuffix(line.Text, "\n")); header != nil {
+			if location, ok := parseLocation(header[2]); ok {
+				add(location)
+			}
+		}
+	}
+	return locations
+}
+
+// RelativeFile returns the slash separated path of the absolute file relative to the working directory.
+// False if the file is outside of the working directory.
+func RelativeFile(file string) (string, bool) {
+	wd, err := filepath.Abs(".")
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// SourceFile returns the slash separated path of the location's file relative to the working directory.
+// Relative files are joined with the directory of the package if it is known and returned unchanged otherwise.
+// Absolute files, such as in the error trace of testify, are false if they are outside of the working directory.
+func (p PackageResult) SourceFile(location Location) (string, bool) {
+	if filepath.IsAbs(location.File) {
+		return RelativeFile(location.File)
+	}
+	if p.Dir == "" {
+		return location.File, true
+	}
+	return path.Join(p.Dir, path.Base(filepath.ToSlash(location.File))), true
+}
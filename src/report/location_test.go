@@ -0,0 +1,60 @@
+package report_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestResultLocations(t *testing.T) {
+	test := report.TestResult{
+		Assertions: []report.Assertion{
+			{Trace: "/src/repo/foo_test.go:12\n\t/src/repo/helper_test.go:5"},
+			{Trace: "not a location"},
+		},
+		Output: outputLines("=== RUN   TestFoo\n" +
+			"    foo_test.go:7: some log\n" +
+			"    foo_test.go:7: some log again\n" +
+			"        sub_test.go:3: \n" +
+			"    main.go:abc: no location\n" +
+			"--- FAIL: TestFoo (0.00s)\n"),
+	}
+
+	assert.Equal(t, []report.Location{
+		{File: "/src/repo/foo_test.go", Line: 12},
+		{File: "/src/repo/helper_test.go", Line: 5},
+		{File: "foo_test.go", Line: 7},
+		{File: "sub_test.go", Line: 3},
+	}, test.Locations())
+	assert.Empty(t, report.TestResult{}.Locations())
+	assert.Equal(t, "foo_test.go:7", report.Location{File: "foo_test.go", Line: 7}.String())
+}
+
+func TestPackageResultSourceFile(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	require.Nil(t, err)
+	var suite = []struct {
+		dir  string
+		file string
+		exp  string
+		ok   bool
+	}{
+		{"", "a_test.go", "a_test.go", true},
+		{"pkg/a", "a_test.go", "pkg/a/a_test.go", true},
+		{"pkg/a", "sub/a_test.go", "pkg/a/a_test.go", true},
+		{".", "a_test.go", "a_test.go", true},
+		// Error traces of testify are absolute
+		{"pkg/a", filepath.Join(wd, "sub", "a_test.go"), "sub/a_test.go", true},
+		{"pkg/a", filepath.Join(filepath.Dir(wd), "a_test.go"), "", false},
+	}
+	for _, s := range suite {
+		t.Run(s.dir+" "+s.file, func(t *testing.T) {
+			file, ok := report.PackageResult{Dir: s.dir}.SourceFile(report.Location{File: s.file, Line: 1})
+			assert.Equal(t, s.ok, ok)
+			assert.Equal(t, s.exp, file)
+		})
+	}
+}
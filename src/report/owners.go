@@ -0,0 +1,65 @@
+package report
+
+import "sort"
+
+// Failure references a failed test or a package which failed without a failed test.
+type Failure struct {
+	Package PackageName
+	Test    string // empty if the package failed to build or crashed
+}
+
+// OwnerFailures are the failures which are owned by a code owner.
+type OwnerFailures struct {
+	Owner    string // empty for failures without code owner
+	Failures []Failure
+}
+
+// FailureOwners groups all failures by their code owners. Failures with multiple owners
+// are listed for each owner. Quarantined and flaky tests are not included. The owners with the most
+// failures come first. Returns nil if no package has code owners.
+func (r Result) FailureOwners() (owners []OwnerFailures) {
+	hasOwners := false
+	for _, pack := range r.PackageResult {
+		hasOwners = hasOwners || len(pack.Owners) > 0
+	}
+	if !hasOwners {
+		return nil
+	}
+	byOwner := make(map[string]*OwnerFailures)
+	add := func(failure Failure, failureOwners []string) {
+		if len(failureOwners) == 0 {
+			failureOwners = []string{""}
+		}
+		for _, owner := range failureOwners {
+			if _, ok := byOwner[owner]; !ok {
+				byOwner[owner] = &OwnerFailures{Owner: owner}
+			}
+			byOwner[owner].Failures = append(byOwner[owner].Failures, failure)
+		}
+	}
+	for _, pack := range r.PackageResult {
+		if !pack.Failed() {
+			continue
+		}
+		failedTests := 0
+		for _, test := range pack.Tests {
+			if test.PersistentFailure() {
+				failedTests++
+				add(Failure{Package: pack.Name, Test: test.Name}, test.Owners)
+			}
+		}
+		if failedTests == 0 {
+			add(Failure{Package: pack.Name}, pack.Owners)
+		}
+	}
+	for _, owner := range byOwner {
+		owners = append(owners, *owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if len(owners[i].Failures) != len(owners[j].Failures) {
+			return len(owners[i].Failures) > len(owners[j].Failures)
+		}
+		return owners[i].Owner < owners[j].Owner
+	})
+	return owners
+}
@@ -0,0 +1,64 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureOwners(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "a", PackageResult: report.FTSFail, Owners: []string{"@a"}, Tests: []report.TestResult{
+			{Name: "TestA", TestResult: report.FTSFail, Owners: []string{"@a", "@b"}},
+			{Name: "TestB", TestResult: report.FTSFail, Owners: []string{"@b"}},
+			{Name: "TestFlaky", TestResult: report.FTSFail, Owners: []string{"@c"}, Quarantine: &report.QuarantineEntry{}},
+			{Name: "TestC", TestResult: report.FTSFail},
+			{Name: "TestPass", TestResult: report.FTSPass, Owners: []string{"@a"}},
+		}},
+		{Name: "build", PackageResult: report.FTSFail, Owners: []string{"@c"}},
+		{Name: "ok", PackageResult: report.FTSPass, Owners: []string{"@d"}},
+	}}
+
+	assert.Equal(t, []report.OwnerFailures{
+		{Owner: "@b", Failures: []report.Failure{{Package: "a", Test: "TestA"}, {Package: "a", Test: "TestB"}}},
+		{Owner: "", Failures: []report.Failure{{Package: "a", Test: "TestC"}}},
+		{Owner: "@a", Failures: []report.Failure{{Package: "a", Test: "TestA"}}},
+		{Owner: "@c", Failures: []report.Failure{{Package: "build"}}},
+	}, result.FailureOwners())
+	assert.Nil(t, report.Result{PackageResult: []report.PackageResult{{Name: "a", PackageResult: report.FTSFail}}}.FailureOwners())
+}
+
+func TestFilterApply_Owner(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "a", Owners: []string{"@org/api", "@org/core"}},
+		{Name: "b", Owners: []string{"@org/storage"}},
+		{Name: "c"},
+	}}
+	var suite = []struct {
+		include  string
+		exclude  string
+		packages []report.PackageName
+	}{
+		{"owner:@org/api", "", []report.PackageName{"a"}},
+		{"owner:@org/*", "", []report.PackageName{"a", "b"}},
+		{"", "owner:@org/core", []report.PackageName{"b", "c"}},
+	}
+	for _, s := range suite {
+		t.Run(s.include+s.exclude, func(t *testing.T) {
+			var filter report.Filter
+			if s.include != "" {
+				require.Nil(t, filter.AddPattern(s.include, true))
+			}
+			if s.exclude != "" {
+				require.Nil(t, filter.AddPattern(s.exclude, false))
+			}
+			packages := []report.PackageName{}
+			for _, pack := range filter.Apply(result).PackageResult {
+				packages = append(packages, pack.Name)
+			}
+			assert.Equal(t, s.packages, packages)
+		})
+	}
+}
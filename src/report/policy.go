@@ -0,0 +1,127 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExitPolicy defines which test results are treated as failure of the test run.
+// The zero value never fails.
+type ExitPolicy struct {
+	FailedPackages bool          // a package failed, also if the package failed to build
+	FailedTests    bool          // at least one test failed
+	Skipped        bool          // at least one test was skipped
+	Incomplete     bool          // at least one test never finished
+	MinPassRate    float64       // percentage of passed tests out of all tests which were not skipped
+	MaxDuration    time.Duration // upper limit for the total duration of all packages
+	Budget         bool          // at least one test or package exceeded its duration budget
+}
+
+// ParseExitPolicy parses a comma separated list of conditions.
+// For example "failed-tests,pass-rate:95,duration:10m". The condition "never" must be the only condition.
+func ParseExitPolicy(input string) (policy ExitPolicy, err error) {
+	var conditions []string
+	for _, condition := range strings.Split(input, ",") {
+		if condition = strings.TrimSpace(condition); condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+	for _, condition := range conditions {
+		if condition == "never" && len(conditions) > 1 {
+			return ExitPolicy{}, fmt.Errorf("condition never disables all other conditions and can not be combined with them, got %s", input)
+		}
+	}
+	for _, condition := range conditions {
+		name, value, hasValue := strings.Cut(condition, ":")
+		if !hasValue {
+			switch name {
+			case "never":
+				return ExitPolicy{}, nil
+			case "failure":
+				policy.FailedPackages = true
+			case "failed-tests":
+				policy.FailedTests = true
+			case "skipped":
+				policy.Skipped = true
+			case "incomplete":
+				policy.Incomplete = true
+			case "budget":
+				policy.Budget = true
+			default:
+				return ExitPolicy{}, fmt.Errorf("unknown exit condition %s", name)
+			}
+			continue
+		}
+		switch name {
+		case "pass-rate":
+			policy.MinPassRate, err = strconv.ParseFloat(value, 64)
+			if err != nil || policy.MinPassRate < 0 || policy.MinPassRate > 100 {
+				return ExitPolicy{}, fmt.Errorf("pass-rate must be a percentage between 0 and 100, got %s", value)
+			}
+		case "duration":
+			policy.MaxDuration, err = time.ParseDuration(value)
+			if err != nil || policy.MaxDuration <= 0 {
+				return ExitPolicy{}, fmt.Errorf("duration must be a positive duration such as 5m, got %s", value)
+			}
+		default:
+			return ExitPolicy{}, fmt.Errorf("unknown exit condition %s", name)
+		}
+	}
+	return policy, nil
+}
+
+// PassRate is the percentage of passed tests out of all tests which were not skipped. Like in the tests badge,
+// flaky tests count as passed and quarantined failures are not counted.
+func (r Result) PassRate() float64 {
+	var passed, executed uint
+	for _, pack := range r.PackageResult {
+		for _, test := range pack.Tests {
+			switch {
+			case test.Flaky() || test.TestResult == FTSPass:
+				passed++
+				executed++
+			case test.PersistentFailure() || test.TestResult == FTSIncomplete:
+				executed++
+			}
+		}
+	}
+	if executed == 0 {
+		return 100
+	}
+	return float64(passed) * 100 / float64(executed)
+}
+
+// Violations returns a description for each condition of the policy which is violated by the result.
+// Quarantined and flaky tests are not treated as failed.
+func (p ExitPolicy) Violations(result Result) (violations []string) {
+	if p.FailedPackages {
+		for _, pack := range result.PackageResult {
+			if pack.Failed() {
+				violations = append(violations, fmt.Sprintf("package %s failed", pack.Name))
+			}
+		}
+	}
+	if p.FailedTests {
+		if failed := result.PersistentFailures(); failed > 0 {
+			violations = append(violations, fmt.Sprintf("%d tests failed", failed))
+		}
+	}
+	if p.Skipped && result.Skipped > 0 {
+		violations = append(violations, fmt.Sprintf("%d tests were skipped", result.Skipped))
+	}
+	if p.Incomplete && result.Incomplete > 0 {
+		violations = append(violations, fmt.Sprintf("%d tests did not finish", result.Incomplete))
+	}
+	if p.MinPassRate > 0 && result.PassRate() < p.MinPassRate {
+		violations = append(violations, fmt.Sprintf("pass rate %.2f%% is below %.2f%%", result.PassRate(), p.MinPassRate))
+	}
+	if p.MaxDuration > 0 && result.Duration > p.MaxDuration {
+		violations = append(violations, fmt.Sprintf("duration %s exceeds %s", result.Duration, p.MaxDuration))
+	}
+	if p.Budget && len(result.BudgetViolations) > 0 {
+		violations = append(violations, fmt.Sprintf("%d duration budgets exceeded", len(result.BudgetViolations)))
+	}
+	return violations
+}
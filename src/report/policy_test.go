@@ -0,0 +1,127 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExitPolicy(t *testing.T) {
+	var suite = []struct {
+		in     string
+		policy report.ExitPolicy
+		isErr  bool
+	}{
+		{"", report.ExitPolicy{}, false},
+		{"never", report.ExitPolicy{}, false},
+		{" never, ", report.ExitPolicy{}, false},
+		{"failure", report.ExitPolicy{FailedPackages: true}, false},
+		{"failed-tests,skipped,incomplete", report.ExitPolicy{FailedTests: true, Skipped: true, Incomplete: true}, false},
+		{"budget", report.ExitPolicy{Budget: true}, false},
+		{"pass-rate:99.5, duration:1m30s", report.ExitPolicy{MinPassRate: 99.5, MaxDuration: time.Second * 90}, false},
+
+		{"foo", report.ExitPolicy{}, true},
+		{"foo:1", report.ExitPolicy{}, true},
+		{"never,failure", report.ExitPolicy{}, true},
+		{"failure,never", report.ExitPolicy{}, true},
+		{"never,pass-rate:95", report.ExitPolicy{}, true},
+		{"pass-rate", report.ExitPolicy{}, true},
+		{"pass-rate:101", report.ExitPolicy{}, true},
+		{"pass-rate:x", report.ExitPolicy{}, true},
+		{"duration:-1s", report.ExitPolicy{}, true},
+		{"duration:10", report.ExitPolicy{}, true},
+	}
+	for _, s := range suite {
+		t.Run(s.in, func(t *testing.T) {
+			policy, err := report.ParseExitPolicy(s.in)
+			assert.Equal(t, s.isErr, err != nil)
+			assert.Equal(t, s.policy, policy)
+		})
+	}
+}
+
+func TestExitPolicyViolations(t *testing.T) {
+	result := report.Result{
+		Tests:      10,
+		Passed:     6,
+		Failed:     2,
+		Skipped:    1,
+		Incomplete: 1,
+		Duration:   time.Minute,
+		BudgetViolations: []report.BudgetViolation{
+			{Package: "foo", Duration: time.Minute, Budget: time.Second},
+		},
+		PackageResult: []report.PackageResult{
+			{Name: "foo", PackageResult: report.FTSFail, Tests: []report.TestResult{
+				{Name: "TestA", TestResult: report.FTSFail},
+				{Name: "TestB", TestResult: report.FTSFail},
+			}},
+			{Name: "bar", PackageResult: report.FTSPass, Tests: []report.TestResult{
+				{Name: "TestA", TestResult: report.FTSPass},
+				{Name: "TestB", TestResult: report.FTSPass},
+				{Name: "TestC", TestResult: report.FTSPass},
+				{Name: "TestD", TestResult: report.FTSPass},
+				{Name: "TestE", TestResult: report.FTSPass},
+				{Name: "TestF", TestResult: report.FTSPass},
+				{Name: "TestG", TestResult: report.FTPSSkip},
+				{Name: "TestH", TestResult: report.FTSIncomplete},
+			}},
+		},
+	}
+	var suite = []struct {
+		name       string
+		policy     report.ExitPolicy
+		violations []string
+	}{
+		{"never", report.ExitPolicy{}, nil},
+		{"failure", report.ExitPolicy{FailedPackages: true}, []string{"package foo failed"}},
+		{"failed-tests", report.ExitPolicy{FailedTests: true}, []string{"2 tests failed"}},
+		{"skipped", report.ExitPolicy{Skipped: true}, []string{"1 tests were skipped"}},
+		{"incomplete", report.ExitPolicy{Incomplete: true}, []string{"1 tests did not finish"}},
+		{"pass-rate ok", report.ExitPolicy{MinPassRate: 66}, nil},
+		{"pass-rate", report.ExitPolicy{MinPassRate: 67}, []string{"pass rate 66.67% is below 67.00%"}},
+		{"duration ok", report.ExitPolicy{MaxDuration: time.Minute}, nil},
+		{"duration", report.ExitPolicy{MaxDuration: time.Second}, []string{"duration 1m0s exceeds 1s"}},
+		{"budget", report.ExitPolicy{Budget: true}, []string{"1 duration budgets exceeded"}},
+	}
+	for _, s := range suite {
+		t.Run(s.name, func(t *testing.T) {
+			assert.Equal(t, s.violations, s.policy.Violations(result))
+		})
+	}
+}
+
+func TestExitPolicyViolations_IgnoreQuarantinedAndFlaky(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "foo", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestQuarantined", TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+			{Name: "TestFlaky", TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}},
+		}},
+	}}
+	result.UpdateTotals()
+	assert.Empty(t, report.ExitPolicy{FailedTests: true, FailedPackages: true}.Violations(result))
+}
+
+func TestPassRate(t *testing.T) {
+	var suite = []struct {
+		name  string
+		tests []report.TestResult
+		rate  float64
+	}{
+		{"no tests", nil, 100},
+		{"skipped", []report.TestResult{{TestResult: report.FTPSSkip}, {TestResult: report.FTPSSkip}}, 100},
+		{"failed", []report.TestResult{{TestResult: report.FTSPass}, {TestResult: report.FTSFail}, {TestResult: report.FTPSSkip}}, 50},
+		{"incomplete", []report.TestResult{{TestResult: report.FTSPass}, {TestResult: report.FTSIncomplete}}, 50},
+		{"flaky", []report.TestResult{{TestResult: report.FTSPass}, {TestResult: report.FTSFail, Attempts: []report.Attempt{{TestResult: report.FTSPass}}}}, 100},
+		{"quarantined", []report.TestResult{{TestResult: report.FTSPass}, {TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}}}, 100},
+	}
+	for _, s := range suite {
+		t.Run(s.name, func(t *testing.T) {
+			result := report.Result{PackageResult: []report.PackageResult{{Name: "foo", Tests: s.tests}}}
+			result.UpdateTotals()
+			assert.Equal(t, s.rate, result.PassRate())
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const quarantineDateLayout = "2006-01-02"
+
+// QuarantineEntry marks a known flaky test. Failures of quarantined tests are reported,
+// but are not treated as failures for the exit code.
+type QuarantineEntry struct {
+	// Test name or regular expression if Regex is set. A test name also matches all of its subtests.
+	Test string `json:"test"`
+	// Package import path or regular expression if Regex is set. Matches all packages if empty.
+	Package string `json:"package,omitempty"`
+	Regex   bool   `json:"regex,omitempty"`
+	// Expires is the last day (YYYY-MM-DD) on which the entry is valid.
+	Expires string `json:"expires,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+
+	testRegex    *regexp.Regexp
+	packageRegex *regexp.Regexp
+	expires      time.Time
+}
+
+func (e QuarantineEntry) String() string {
+	if e.Package == "" {
+		return e.Test
+	}
+	return e.Package + " " + e.Test
+}
+
+// ExpiresAt returns the time when the entry expires or the zero time if it never expires.
+func (e QuarantineEntry) ExpiresAt() time.Time {
+	return e.expires
+}
+
+func (e QuarantineEntry) matches(pack PackageName, test string) bool {
+	if e.Regex {
+		return (e.packageRegex == nil || e.packageRegex.MatchString(string(pack))) && e.testRegex.MatchString(test)
+	}
+	return (e.Package == "" || e.Package == string(pack)) && (e.Test == test || strings.HasPrefix(test, e.Test+"/"))
+}
+
+// QuarantineStatus is the state of a single quarantine entry after it was applied to a result.
+type QuarantineStatus struct {
+	Entry   QuarantineEntry
+	Matched int
+	Failed  int
+	Expired bool
+}
+
+// Passing is true if all tests which matched the entry passed.
+func (s QuarantineStatus) Passing() bool {
+	return s.Matched > 0 && s.Failed == 0
+}
+
+// Stale is true if the entry can probably be removed from the quarantine list.
+func (s QuarantineStatus) Stale() bool {
+	return s.Expired || s.Passing()
+}
+
+type Quarantine []QuarantineEntry
+
+func LoadQuarantine(pathToFile string) (quarantine Quarantine, err error) {
+	content, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQuarantine(content)
+}
+
+func ParseQuarantine(content []byte) (quarantine Quarantine, err error) {
+	if err := json.Unmarshal(content, &quarantine); err != nil {
+		return nil, fmt.Errorf("invalid quarantine list. %s", err)
+	}
+	for idx := range quarantine {
+		entry := &quarantine[idx]
+		if entry.Test == "" {
+			return nil, fmt.Errorf("quarantine entry %d has no test", idx)
+		}
+		if entry.Regex {
+			if entry.testRegex, err = regexp.Compile(entry.Test); err != nil {
+				return nil, fmt.Errorf("invalid test regex of quarantine entry %d. %s", idx, err)
+			}
+			if entry.Package != "" {
+				if entry.packageRegex, err = regexp.Compile(entry.Package); err != nil {
+					return nil, fmt.Errorf("invalid package regex of quarantine entry %d. %s", idx, err)
+				}
+			}
+		}
+		if entry.Expires != "" {
+			expires, err := time.Parse(quarantineDateLayout, entry.Expires)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expiry date of quarantine entry %d. %s", idx, err)
+			}
+			entry.expires = expires.AddDate(0, 0, 1)
+		}
+	}
+	return quarantine, nil
+}
+
+// Apply marks all tests of the result which match an entry of the quarantine list.
+// A failed test is also quarantined if all of its failed subtests are quarantined and it has
+// no failure output of its own.
+// Expired entries do not quarantine any tests.
+func (q Quarantine) Apply(result *Result, now time.Time) {
+	result.Quarantine = make([]QuarantineStatus, len(q))
+	for idx, entry := range q {
+		result.Quarantine[idx] = QuarantineStatus{
+			Entry:   entry,
+			Expired: !entry.expires.IsZero() && !now.Before(entry.expires),
+		}
+	}
+	result.Quarantined = 0
+	for pIdx := range result.PackageResult {
+		pack := &result.PackageResult[pIdx]
+		for tIdx := range pack.Tests {
+			test := &pack.Tests[tIdx]
+			test.Quarantine = nil
+			for eIdx := range q {
+				status := &result.Quarantine[eIdx]
+				if !q[eIdx].matches(pack.Name, test.Name) {
+					continue
+				}
+				status.Matched++
+				if test.TestResult == FTSFail {
+					status.Failed++
+				}
+				if test.Quarantine == nil && !status.Expired {
+					test.Quarantine = &result.Quarantine[eIdx].Entry
+				}
+			}
+		}
+		// Resolve the deepest subtests first so that the result propagates up to the root test.
+		byDepth := make([]int, len(pack.Tests))
+		for tIdx := range byDepth {
+			byDepth[tIdx] = tIdx
+		}
+		sort.SliceStable(byDepth, func(i, j int) bool {
+			return strings.Count(pack.Tests[byDepth[i]].Name, "/") > strings.Count(pack.Tests[byDepth[j]].Name, "/")
+		})
+		for _, tIdx := range byDepth {
+			test := &pack.Tests[tIdx]
+			if test.TestResult != FTSFail || test.Quarantine != nil || hasOwnOutput(*test) {
+				continue
+			}
+			test.Quarantine = quarantinedSubtests(pack.Tests, test.Name)
+		}
+		for _, test := range pack.Tests {
+			if test.TestResult == FTSFail && test.Quarantine != nil {
+				result.Quarantined++
+			}
+		}
+	}
+}
+
+// hasOwnOutput is true if the test logged output other than the test framing such as "=== RUN", which
+// for example is the case if the test itself failed besides its subtests.
+func hasOwnOutput(test TestResult) bool {
+	for _, line := range test.Output {
+		text := strings.TrimSpace(line.Text)
+		if text != "" && !strings.HasPrefix(text, "=== ") && !strings.HasPrefix(text, "--- ") {
+			return true
+		}
+	}
+	return false
+}
+
+// quarantinedSubtests returns the quarantine entry of the failed subtests of the parent
+// test if all of them are quarantined.
+func quarantinedSubtests(tests []TestResult, parent string) (entry *QuarantineEntry) {
+	for _, test := range tests {
+		if test.TestResult != FTSFail || !strings.HasPrefix(test.Name, parent+"/") {
+			continue
+		}
+		if test.Quarantine == nil {
+			return nil
+		}
+		entry = test.Quarantine
+	}
+	return entry
+}
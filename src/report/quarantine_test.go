@@ -0,0 +1,115 @@
+package report_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuarantine(t *testing.T) {
+	var suite = []struct {
+		in    string
+		isErr bool
+	}{
+		{`[]`, false},
+		{`[{"test":"TestFoo"}]`, false},
+		{`[{"test":"^Test.*$","package":"foo/.*","regex":true,"expires":"2023-01-31","owner":"@me"}]`, false},
+
+		{``, true},
+		{`{}`, true},
+		{`[{"package":"foo"}]`, true},
+		{`[{"test":"(","regex":true}]`, true},
+		{`[{"test":"a","package":"(","regex":true}]`, true},
+		{`[{"test":"a","expires":"31.01.2023"}]`, true},
+	}
+	for _, s := range suite {
+		t.Run(s.in, func(t *testing.T) {
+			_, err := report.ParseQuarantine([]byte(s.in))
+			assert.Equal(t, s.isErr, err != nil)
+		})
+	}
+}
+
+func TestQuarantineApply(t *testing.T) {
+	quarantine, err := report.ParseQuarantine([]byte(`[
+		{"test":"TestFlaky","package":"foo"},
+		{"test":"TestParent/sub"},
+		{"test":"^TestPass","regex":true},
+		{"test":"TestExpired","expires":"2023-01-31"}
+	]`))
+	require.Nil(t, err)
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "foo", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestFlaky", TestResult: report.FTSFail},
+			{Name: "TestFlaky/sub", TestResult: report.FTSFail},
+			{Name: "TestParent", TestResult: report.FTSFail},
+			{Name: "TestParent/sub", TestResult: report.FTSFail},
+			{Name: "TestParent/sub/a", TestResult: report.FTSFail},
+			{Name: "TestPassing", TestResult: report.FTSPass},
+		}},
+		{Name: "bar", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestFlaky", TestResult: report.FTSFail},
+			{Name: "TestExpired", TestResult: report.FTSFail},
+			{Name: "TestParent", TestResult: report.FTSFail, Output: []report.OutputLine{
+				{Text: "=== RUN   TestParent\n"}, {Text: "    bar_test.go:10: setup failed\n"}, {Text: "--- FAIL: TestParent (0.00s)\n"}}},
+			{Name: "TestParent/sub", TestResult: report.FTSFail},
+		}},
+	}}
+
+	quarantine.Apply(&result, time.Date(2023, 1, 31, 23, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, uint(7), result.Quarantined)
+	for _, test := range result.PackageResult[0].Tests {
+		assert.NotNil(t, test.Quarantine, test.Name)
+	}
+	assert.False(t, result.PackageResult[0].Failed())
+	assert.Nil(t, result.PackageResult[1].Tests[0].Quarantine)
+	assert.NotNil(t, result.PackageResult[1].Tests[1].Quarantine)
+	assert.Nil(t, result.PackageResult[1].Tests[2].Quarantine, "the parent failed itself")
+	assert.NotNil(t, result.PackageResult[1].Tests[3].Quarantine)
+	assert.True(t, result.PackageResult[1].Failed())
+	require.Len(t, result.StaleQuarantine(), 1)
+	assert.True(t, result.StaleQuarantine()[0].Passing())
+
+	quarantine.Apply(&result, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, uint(6), result.Quarantined)
+	assert.Nil(t, result.PackageResult[1].Tests[1].Quarantine)
+	stale := result.StaleQuarantine()
+	require.Len(t, stale, 2)
+	assert.True(t, stale[0].Passing())
+	assert.Equal(t, "^TestPass", stale[0].Entry.Test)
+	assert.True(t, stale[1].Expired)
+	assert.Equal(t, "TestExpired", stale[1].Entry.Test)
+}
+
+func TestPackageResultFailed(t *testing.T) {
+	var suite = []struct {
+		res    report.PackageResult
+		failed bool
+	}{
+		{report.PackageResult{PackageResult: report.FTSPass}, false},
+		{report.PackageResult{PackageResult: report.FTPSSkip}, false},
+		{report.PackageResult{PackageResult: report.FTSFail}, true},
+		{report.PackageResult{PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{TestResult: report.FTSFail},
+		}}, true},
+		{report.PackageResult{PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+			{TestResult: report.FTSPass},
+		}}, false},
+		{report.PackageResult{PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{TestResult: report.FTSFail, Quarantine: &report.QuarantineEntry{}},
+			{TestResult: report.FTSFail},
+		}}, true},
+	}
+	for i, s := range suite {
+		t.Run(fmt.Sprintf("(%d)", i), func(t *testing.T) {
+			assert.Equal(t, s.failed, s.res.Failed())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FailedTestNames returns the names of the failed tests without the parent tests which
+// only failed because one of their subtests failed.
+func (p PackageResult) FailedTestNames() (names []string) {
+	for _, test := range p.Tests {
+		if test.TestResult != FTSFail {
+			continue
+		}
+		hasFailedSubtest := false
+		for _, other := range p.Tests {
+			if other.TestResult == FTSFail && strings.HasPrefix(other.Name, test.Name+"/") {
+				hasFailedSubtest = true
+				break
+			}
+		}
+		if !hasFailedSubtest {
+			names = append(names, test.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParentTest returns the longest test name of which the test is a subtest. Subtest names may contain slashes,
+// such as TestA/example.com/a, so the parent is looked up in the names of the package. If the parent is not
+// part of the result, for example because it was filtered, the name up to the last slash is the parent.
+func ParentTest(names map[string]bool, test string) string {
+	for idx := strings.LastIndex(test, "/"); idx > 0; idx = strings.LastIndex(test[:idx], "/") {
+		if names[test[:idx]] {
+			return test[:idx]
+		}
+	}
+	if idx := strings.LastIndex(test, "/"); idx >= 0 {
+		return test[:idx]
+	}
+	return ""
+}
+
+// RunRegex returns a regular expression for the go test -run flag which only matches the tests.
+// Every level of a subtest name is matched exactly.
+func RunRegex(testNames []string) string {
+	alternatives := make([]string, 0, len(testNames))
+	seen := make(map[string]bool)
+	for _, name := range testNames {
+		levels := strings.Split(name, "/")
+		for idx, level := range levels {
+			levels[idx] = "^" + regexp.QuoteMeta(level) + "$"
+		}
+		alternative := strings.Join(levels, "/")
+		if !seen[alternative] {
+			seen[alternative] = true
+			alternatives = append(alternatives, alternative)
+		}
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// Target returns the package argument for the go test command. This is the relative
+// directory if known and the import path otherwise.
+func (p PackageResult) Target() string {
+	switch {
+	case p.Dir == "":
+		return string(p.Name)
+	case p.Dir == "." || strings.HasPrefix(p.Dir, "../"):
+		return p.Dir
+	default:
+		return "./" + p.Dir
+	}
+}
+
+// RerunCommand returns the go test command which runs the failed tests of the package again.
+// If the package failed without failed tests all of its tests are run. Returns an empty string
+// if the package did not fail.
+func (p PackageResult) RerunCommand() string {
+	if p.PackageResult != FTSFail {
+		return ""
+	}
+	return rerunCommand([]string{p.Target()}, p.FailedTestNames())
+}
+
+// RerunCommand returns a single go test command which runs the failed tests of all packages again.
+// If any package failed without failed tests all tests of the failed packages are run.
+// Returns an empty string if no package failed.
+func (r Result) RerunCommand() string {
+	var targets, names []string
+	runAll := false
+	for _, pack := range r.PackageResult {
+		if pack.PackageResult != FTSFail {
+			continue
+		}
+		targets = append(targets, pack.Target())
+		failedTests := pack.FailedTestNames()
+		runAll = runAll || len(failedTests) == 0
+		names = append(names, failedTests...)
+	}
+	if len(targets) == 0 {
+		return ""
+	}
+	if runAll {
+		names = nil
+	}
+	return rerunCommand(targets, names)
+}
+
+func rerunCommand(targets, testNames []string) string {
+	command := "go test " + strings.Join(targets, " ")
+	if len(testNames) > 0 {
+		command += " -run " + ShellQuote(RunRegex(testNames))
+	}
+	return command
+}
+
+// ShellQuote quotes the string with single quotes for a POSIX shell.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
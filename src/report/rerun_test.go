@@ -0,0 +1,110 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/becheran/go-testreport/src/gomod"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunRegex(t *testing.T) {
+	var suite = []struct {
+		names []string
+		regex string
+	}{
+		{nil, ""},
+		{[]string{"TestA"}, "^TestA$"},
+		{[]string{"TestA", "TestB/sub"}, "^TestA$|^TestB$/^sub$"},
+		{[]string{"TestA", "TestA"}, "^TestA$"},
+		{[]string{`TestMarshalJson/[{"data":{"id":"Foo"}}]`}, `^TestMarshalJson$/^\[\{"data":\{"id":"Foo"\}\}\]$`},
+		{[]string{"TestA/a|b/x_(y)"}, `^TestA$/^a\|b$/^x_\(y\)$`},
+	}
+	for _, s := range suite {
+		t.Run(s.regex, func(t *testing.T) {
+			assert.Equal(t, s.regex, report.RunRegex(s.names))
+		})
+	}
+}
+
+func TestFailedTestNames(t *testing.T) {
+	pack := report.PackageResult{Tests: []report.TestResult{
+		{Name: "TestParent", TestResult: report.FTSFail},
+		{Name: "TestParent/sub", TestResult: report.FTSFail},
+		{Name: "TestParent/ok", TestResult: report.FTSPass},
+		{Name: "TestParentB", TestResult: report.FTSFail},
+		{Name: "TestB", TestResult: report.FTSFail},
+		{Name: "TestC", TestResult: report.FTSPass},
+	}}
+	assert.Equal(t, []string{"TestB", "TestParent/sub", "TestParentB"}, pack.FailedTestNames())
+}
+
+func TestParentTest(t *testing.T) {
+	names := map[string]bool{"TestA": true, "TestA/example.com/a": true}
+	var suite = []struct {
+		test   string
+		parent string
+	}{
+		{"TestA", ""},
+		{"TestA/example.com/a", "TestA"},
+		{"TestA/example.com/a/sub", "TestA/example.com/a"},
+		{"TestB/filtered/sub", "TestB/filtered"},
+	}
+	for _, s := range suite {
+		t.Run(s.test, func(t *testing.T) {
+			assert.Equal(t, s.parent, report.ParentTest(names, s.test))
+		})
+	}
+}
+
+func TestPackageResultTarget(t *testing.T) {
+	assert.Equal(t, "example.com/a", report.PackageResult{Name: "example.com/a"}.Target())
+	assert.Equal(t, ".", report.PackageResult{Name: "example.com/a", Dir: "."}.Target())
+	assert.Equal(t, "./pkg/a", report.PackageResult{Name: "example.com/a", Dir: "pkg/a"}.Target())
+	assert.Equal(t, "../other", report.PackageResult{Name: "example.com/a", Dir: "../other"}.Target())
+}
+
+func TestRerunCommand(t *testing.T) {
+	failed := report.PackageResult{Name: "example.com/a", Dir: "a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "TestA", TestResult: report.FTSFail},
+		{Name: "TestA/it's", TestResult: report.FTSFail},
+	}}
+	failedB := report.PackageResult{Name: "example.com/b", PackageResult: report.FTSFail, Tests: []report.TestResult{
+		{Name: "TestB", TestResult: report.FTSFail},
+	}}
+	buildFailure := report.PackageResult{Name: "example.com/c", Dir: "c", PackageResult: report.FTSFail}
+	passed := report.PackageResult{Name: "example.com/d", Dir: "d", PackageResult: report.FTSPass}
+
+	assert.Equal(t, `go test ./a -run '^TestA$/^it'\''s$'`, failed.RerunCommand())
+	assert.Equal(t, "go test ./c", buildFailure.RerunCommand())
+	assert.Equal(t, "", passed.RerunCommand())
+
+	var suite = []struct {
+		packages []report.PackageResult
+		command  string
+	}{
+		{nil, ""},
+		{[]report.PackageResult{passed}, ""},
+		{[]report.PackageResult{failed, passed, failedB}, `go test ./a example.com/b -run '^TestA$/^it'\''s$|^TestB$'`},
+		{[]report.PackageResult{failed, buildFailure}, "go test ./a ./c"},
+	}
+	for _, s := range suite {
+		t.Run(s.command, func(t *testing.T) {
+			assert.Equal(t, s.command, report.Result{PackageResult: s.packages}.RerunCommand())
+		})
+	}
+}
+
+func TestSetModules(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/repo"},
+		{Name: "example.com/repo/pkg/a"},
+		{Name: "other.com/x", Dir: "stale"},
+	}}
+	result.SetModules([]gomod.Module{{Path: "example.com/repo", Dir: "."}}, ".")
+
+	assert.Equal(t, ".", result.PackageResult[0].Dir)
+	assert.Equal(t, "pkg/a", result.PackageResult[1].Dir)
+	assert.Equal(t, "", result.PackageResult[2].Dir)
+	assert.Len(t, result.Modules, 1)
+}
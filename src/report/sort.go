@@ -0,0 +1,121 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type SortOrder uint8
+
+const (
+	SortByStatus   SortOrder = iota // failed first, then by slowest duration
+	SortByName                      // alphabetically by package and test name
+	SortByPath                      // alphabetically by full package import path and test name
+	SortByStart                     // by the time the first event was received
+	SortByDuration                  // slowest first
+)
+
+var sortOrderStrings = []string{"status", "name", "path", "start", "duration"}
+
+func (o SortOrder) String() string {
+	if int(o) < len(sortOrderStrings) {
+		return sortOrderStrings[o]
+	}
+	return "unknown"
+}
+
+func ParseSortOrder(s string) (SortOrder, error) {
+	for idx, str := range sortOrderStrings {
+		if str == s {
+			return SortOrder(idx), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sort order %s. Expected one of %s", s, strings.Join(sortOrderStrings, ", "))
+}
+
+// Start returns the time of the first event of the test.
+func (t TestResult) Start() time.Time {
+	if !t.Started.IsZero() {
+		return t.Started
+	}
+	if len(t.Output) == 0 {
+		return time.Time{}
+	}
+	return t.Output[0].Time
+}
+
+// Start returns the time of the first event of the package or the start time of its earliest test.
+func (p PackageResult) Start() (start time.Time) {
+	if !p.Started.IsZero() {
+		return p.Started
+	}
+	for _, test := range p.Tests {
+		if testStart := test.Start(); !testStart.IsZero() && (start.IsZero() || testStart.Before(start)) {
+			start = testStart
+		}
+	}
+	return start
+}
+
+// compareNames compares test names segment by segment so that subtests follow their parent test.
+func compareNames(a, b string) int {
+	aSegments, bSegments := strings.Split(a, "/"), strings.Split(b, "/")
+	for idx := 0; idx < len(aSegments) && idx < len(bSegments); idx++ {
+		if cmp := strings.Compare(aSegments[idx], bSegments[idx]); cmp != 0 {
+			return cmp
+		}
+	}
+	return len(aSegments) - len(bSegments)
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// lessBy returns true if a is ordered before b. The comparison falls back to the names
+// to make the order deterministic.
+func lessBy(order SortOrder, aStatus, bStatus FinalTestStatus, aDuration, bDuration time.Duration, aStart, bStart time.Time, aName, bName string) bool {
+	switch order {
+	case SortByStatus:
+		if aStatus != bStatus || aDuration != bDuration {
+			return !IsLess(aStatus, bStatus, aDuration, bDuration)
+		}
+	case SortByStart:
+		if cmp := compareTimes(aStart, bStart); cmp != 0 {
+			return cmp < 0
+		}
+	case SortByDuration:
+		if aDuration != bDuration {
+			return aDuration > bDuration
+		}
+	}
+	return compareNames(aName, bName) < 0
+}
+
+// Sort orders the packages and the tests of each package.
+func (r *Result) Sort(order SortOrder) {
+	for _, pack := range r.PackageResult {
+		tests := pack.Tests
+		sort.SliceStable(tests, func(i, j int) bool {
+			return lessBy(order, tests[i].TestResult, tests[j].TestResult, tests[i].Duration, tests[j].Duration,
+				tests[i].Start(), tests[j].Start(), tests[i].Name, tests[j].Name)
+		})
+	}
+	packages := r.PackageResult
+	sort.SliceStable(packages, func(i, j int) bool {
+		a, b := packages[i], packages[j]
+		if order == SortByName && a.Name.Package() != b.Name.Package() {
+			return a.Name.Package() < b.Name.Package()
+		}
+		return lessBy(order, a.PackageResult, b.PackageResult, a.Duration, b.Duration, a.Start(), b.Start(), string(a.Name), string(b.Name))
+	})
+}
@@ -0,0 +1,89 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	for _, order := range []report.SortOrder{report.SortByStatus, report.SortByName, report.SortByPath, report.SortByStart, report.SortByDuration} {
+		parsed, err := report.ParseSortOrder(order.String())
+		assert.Nil(t, err)
+		assert.Equal(t, order, parsed)
+	}
+	_, err := report.ParseSortOrder("foo")
+	assert.NotNil(t, err)
+}
+
+func TestResultSort(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	test := func(name string, status report.FinalTestStatus, duration time.Duration, startOffset time.Duration) report.TestResult {
+		return report.TestResult{Name: name, TestResult: status, Duration: duration, Output: []report.OutputLine{{Time: start.Add(startOffset)}}}
+	}
+	newResult := func() report.Result {
+		return report.Result{PackageResult: []report.PackageResult{
+			{Name: "z/a", PackageResult: report.FTSPass, Duration: time.Second, Tests: []report.TestResult{
+				test("TestB", report.FTSPass, time.Second, 3),
+				test("TestA/sub", report.FTSFail, time.Second, 2),
+				test("TestAB", report.FTSPass, time.Second, 0),
+				test("TestA", report.FTSFail, time.Second, 1),
+				test("TestC", report.FTSPass, time.Minute, 4),
+			}},
+			{Name: "a/b", PackageResult: report.FTSPass, Duration: time.Second, Tests: []report.TestResult{test("TestA", report.FTSPass, 0, -1)}},
+			{Name: "a/c", PackageResult: report.FTSFail, Duration: time.Millisecond, Tests: []report.TestResult{test("TestA", report.FTSPass, 0, 5)}},
+		}}
+	}
+	var suite = []struct {
+		order    report.SortOrder
+		packages []report.PackageName
+		tests    []string
+	}{
+		{report.SortByStatus, []report.PackageName{"a/c", "a/b", "z/a"}, []string{"TestA", "TestA/sub", "TestC", "TestAB", "TestB"}},
+		{report.SortByName, []report.PackageName{"z/a", "a/b", "a/c"}, []string{"TestA", "TestA/sub", "TestAB", "TestB", "TestC"}},
+		{report.SortByPath, []report.PackageName{"a/b", "a/c", "z/a"}, []string{"TestA", "TestA/sub", "TestAB", "TestB", "TestC"}},
+		{report.SortByStart, []report.PackageName{"a/b", "z/a", "a/c"}, []string{"TestAB", "TestA", "TestA/sub", "TestB", "TestC"}},
+		{report.SortByDuration, []report.PackageName{"a/b", "z/a", "a/c"}, []string{"TestC", "TestA", "TestA/sub", "TestAB", "TestB"}},
+	}
+	for _, s := range suite {
+		t.Run(s.order.String(), func(t *testing.T) {
+			result := newResult()
+			result.Sort(s.order)
+
+			packages := []report.PackageName{}
+			for _, pack := range result.PackageResult {
+				packages = append(packages, pack.Name)
+			}
+			assert.Equal(t, s.packages, packages)
+			tests := []string{}
+			for _, test := range result.PackageResult[indexOf(packages, "z/a")].Tests {
+				tests = append(tests, test.Name)
+			}
+			assert.Equal(t, s.tests, tests)
+
+			// The order must not depend on the input order
+			reversed := newResult()
+			reverse(reversed.PackageResult)
+			reverse(reversed.PackageResult[0].Tests)
+			reversed.Sort(s.order)
+			assert.Equal(t, result, reversed)
+		})
+	}
+}
+
+func indexOf(packages []report.PackageName, name report.PackageName) int {
+	for idx, pack := range packages {
+		if pack == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
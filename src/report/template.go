@@ -2,6 +2,7 @@ package report
 
 import (
 	_ "embed"
+	"os"
 	"path/filepath"
 	"text/template"
 )
@@ -9,13 +10,29 @@ import (
 //go:embed templates/md.tmpl
 var defaultTemplateMarkdown string
 
+//go:embed templates/tree.tmpl
+var treeTemplateMarkdown string
+
+// BuiltinTemplates are the embedded templates which can be selected by name instead of a template file.
+var BuiltinTemplates = map[string]string{
+	"md":   defaultTemplateMarkdown,
+	"tree": treeTemplateMarkdown,
+}
+
 func GetTemplate(pathToTemplate string) (tmp *template.Template, err error) {
 	tmp = template.New(filepath.Base(pathToTemplate)).Funcs(template.FuncMap{
 		"EscapeHtml":     EscapeHtml,
 		"EscapeMarkdown": EscapeMarkdown,
+		"CodeBlock":      CodeBlock,
 	})
 	if pathToTemplate == "" {
 		return template.Must(tmp.Parse(defaultTemplateMarkdown)), nil
 	}
+	// A template file takes precedence over the built-in template of the same name
+	if _, err := os.Stat(pathToTemplate); err != nil {
+		if builtin, ok := BuiltinTemplates[pathToTemplate]; ok {
+			return template.Must(tmp.Parse(builtin)), nil
+		}
+	}
 	return tmp.ParseFiles(pathToTemplate)
 }
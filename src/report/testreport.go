@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"sort"
+	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
+
+	"github.com/becheran/go-testreport/src/gomod"
 )
 
 type FinalTestStatus uint8
@@ -18,6 +20,7 @@ const (
 	FTPSSkip FinalTestStatus = iota
 	FTSPass
 	FTSFail
+	FTSIncomplete // the test started, but never finished
 )
 
 func (fs FinalTestStatus) String() string {
@@ -28,6 +31,8 @@ func (fs FinalTestStatus) String() string {
 		return "fail"
 	case FTPSSkip:
 		return "skip"
+	case FTSIncomplete:
+		return "incomplete"
 	default:
 		return ""
 	}
@@ -41,11 +46,22 @@ func (fs FinalTestStatus) Icon() string {
 		return "❌"
 	case FTPSSkip:
 		return "⏩"
+	case FTSIncomplete:
+		return "⏳"
 	default:
 		return ""
 	}
 }
 
+func FinalTestStatusFromString(s string) (status FinalTestStatus, ok bool) {
+	for _, status := range []FinalTestStatus{FTPSSkip, FTSPass, FTSFail, FTSIncomplete} {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
 func FinalTestStatusFromAction(e TestAction) *FinalTestStatus {
 	var status FinalTestStatus
 	switch e {
@@ -71,6 +87,19 @@ type TestResult struct {
 	Duration   time.Duration
 	Output     []OutputLine
 	TestResult FinalTestStatus
+	Assertions []Assertion
+	Quarantine *QuarantineEntry
+	Budget     time.Duration // zero if no budget applies
+	Owners     []string      // code owners of failed tests
+	Attempts   []Attempt     // reruns of the failed test
+	Started    time.Time     // time of the first event
+	Ended      time.Time     // time of the last event
+	Pauses     []Span        // periods in which the parallel test waited for other tests
+}
+
+// OverBudget is true if the test took longer than its budget.
+func (t TestResult) OverBudget() bool {
+	return t.Budget > 0 && t.Duration > t.Budget
 }
 
 type PackageName string
@@ -97,6 +126,36 @@ type PackageResult struct {
 	PackageResult FinalTestStatus
 	Succeeded     int
 	Tests         []TestResult
+	Budget        time.Duration // zero if no budget applies
+	Owners        []string      // code owners of the package directory
+	Dir           string        // directory relative to the working directory. Empty if unknown
+	Started       time.Time     // time of the first event
+	Ended         time.Time     // time of the last event
+	Output        []OutputLine  // output of the package and its build which does not belong to a test
+	Coverage      *float64      // percentage of covered statements. Nil if the coverage was not measured
+}
+
+// OverBudget is true if the package took longer than its budget.
+func (p PackageResult) OverBudget() bool {
+	return p.Budget > 0 && p.Duration > p.Budget
+}
+
+// Failed is true if the package failed and the failure is not only caused by quarantined or flaky tests.
+func (p PackageResult) Failed() bool {
+	if p.PackageResult != FTSFail {
+		return false
+	}
+	failedTests := 0
+	for _, test := range p.Tests {
+		if test.TestResult == FTSFail {
+			if test.PersistentFailure() {
+				return true
+			}
+			failedTests++
+		}
+	}
+	// A package without failed tests failed to build or test binary crashed
+	return failedTests == 0
 }
 
 func (p PackageResult) String() string {
@@ -129,18 +188,110 @@ func (p PackageResult) String() string {
 }
 
 type Result struct {
-	Failed        uint
-	Passed        uint
-	Skipped       uint
-	Tests         uint
-	Duration      time.Duration
-	PackageResult []PackageResult
-	Vars          map[string]string
+	Failed           uint
+	Passed           uint
+	Skipped          uint
+	Incomplete       uint
+	Tests            uint
+	Duration         time.Duration
+	PackageResult    []PackageResult
+	Vars             map[string]string
+	Modules          []gomod.Module // tested go modules
+	Quarantined      uint
+	Flaky            uint // failed tests which passed on a rerun
+	Quarantine       []QuarantineStatus
+	BudgetViolations []BudgetViolation
+	Coverage         *float64     // percentage of covered statements of the cover profile. Nil without profile
+	CoverProfile     CoverProfile // nil if no cover profile was read
+}
+
+// SetModules sets the tested modules and resolves the directories of all packages
+// relative to the working directory.
+func (r *Result) SetModules(modules []gomod.Module, workDir string) {
+	r.Modules = modules
+	for idx := range r.PackageResult {
+		pack := &r.PackageResult[idx]
+		pack.Dir = ""
+		dir, ok := gomod.PackageDir(modules, string(pack.Name))
+		if !ok {
+			continue
+		}
+		if rel, err := filepath.Rel(workDir, dir); err == nil {
+			pack.Dir = filepath.ToSlash(rel)
+		}
+	}
+}
+
+// PersistentFailures returns the number of failed tests which are neither flaky nor quarantined.
+func (r Result) PersistentFailures() (failures uint) {
+	for _, pack := range r.PackageResult {
+		for _, test := range pack.Tests {
+			if test.PersistentFailure() {
+				failures++
+			}
+		}
+	}
+	return failures
+}
+
+// UpdateTotals recomputes the test counts and durations from the package results.
+// Like in ParseTestJson, the total duration is the sum of all package durations in whole seconds.
+func (r *Result) UpdateTotals() {
+	r.Passed, r.Failed, r.Skipped, r.Incomplete, r.Quarantined, r.Flaky = 0, 0, 0, 0, 0, 0
+	r.Duration = 0
+	for pIdx := range r.PackageResult {
+		pack := &r.PackageResult[pIdx]
+		pack.Succeeded = 0
+		r.Duration += pack.Duration.Truncate(time.Second)
+		for _, test := range pack.Tests {
+			switch test.TestResult {
+			case FTSPass:
+				r.Passed++
+				pack.Succeeded++
+			case FTPSSkip:
+				r.Skipped++
+				pack.Succeeded++
+			case FTSFail:
+				r.Failed++
+				if test.Quarantine != nil {
+					r.Quarantined++
+				}
+				if test.Flaky() {
+					r.Flaky++
+				}
+			case FTSIncomplete:
+				r.Incomplete++
+			}
+		}
+	}
+	r.Tests = r.Passed + r.Failed + r.Skipped + r.Incomplete
+}
+
+// StaleQuarantine returns all quarantine entries which are expired or only matched passing tests.
+func (r Result) StaleQuarantine() (stale []QuarantineStatus) {
+	for _, status := range r.Quarantine {
+		if status.Stale() {
+			stale = append(stale, status)
+		}
+	}
+	return stale
+}
+
+// BuildPackage returns the import path of the package which is built. Build events reference
+// test packages such as "example.com/a [example.com/a.test]", external test packages such as
+// "example.com/a_test [example.com/a.test]" or the test binary "example.com/a.test".
+func BuildPackage(importPath string) string {
+	if idx := strings.Index(importPath, " ["); idx >= 0 {
+		importPath = strings.TrimSuffix(importPath[:idx], "_test")
+	}
+	return strings.TrimSuffix(importPath, ".test")
 }
 
 func ParseTestJson(in io.Reader) (result Result, err error) {
 	packageResult := make(map[string]*PackageResult)
 	testResultForPackage := make(map[string]map[string]*TestResult)
+	buildOutput := make(map[string][]OutputLine)
+	started := make(map[string]bool) // packages with a start event, which go 1.20 and newer write
 	scanner := bufio.NewScanner(in)
 	for scanner.Scan() {
 		line := scanner.Bytes()
@@ -153,6 +304,13 @@ func ParseTestJson(in io.Reader) (result Result, err error) {
 			// Ignore parse errors
 			continue
 		}
+		if evt.Action == TABuildOutput || evt.Action == TABuildFail {
+			if evt.Output != "" {
+				importPath := BuildPackage(evt.ImportPath)
+				buildOutput[importPath] = append(buildOutput[importPath], OutputLine{Time: evt.Time, Text: evt.Output})
+			}
+			continue
+		}
 		if _, packageExists := packageResult[evt.Package]; !packageExists {
 			res := PackageResult{
 				Name: PackageName(evt.Package),
@@ -160,8 +318,15 @@ func ParseTestJson(in io.Reader) (result Result, err error) {
 			packageResult[evt.Package] = &res
 			testResultForPackage[evt.Package] = map[string]*TestResult{}
 		}
+		track(&packageResult[evt.Package].Started, &packageResult[evt.Package].Ended, evt.Time)
+		if evt.Action == TAStart {
+			started[evt.Package] = true
+		}
 
 		if evt.Test == "" {
+			if evt.Action == TAOutput {
+				packageResult[evt.Package].Output = append(packageResult[evt.Package].Output, OutputLine{Time: evt.Time, Text: evt.Output})
+			}
 			if status := FinalTestStatusFromAction(evt.Action); status != nil {
 				packageResult[evt.Package].PackageResult = *status
 				result.Duration += time.Second * time.Duration(evt.ElapsedSec)
@@ -172,12 +337,22 @@ func ParseTestJson(in io.Reader) (result Result, err error) {
 				testRes.Output = append(testRes.Output, OutputLine{Time: evt.Time, Text: evt.Output})
 			} else {
 				testResultForPackage[evt.Package][evt.Test] = &TestResult{
-					Name:   evt.Test,
-					Output: []OutputLine{{Time: evt.Time, Text: evt.Output}},
+					Name:       evt.Test,
+					Output:     []OutputLine{{Time: evt.Time, Text: evt.Output}},
+					TestResult: FTSIncomplete,
+				}
+			}
+			test := testResultForPackage[evt.Package][evt.Test]
+			track(&test.Started, &test.Ended, evt.Time)
+			switch evt.Action {
+			case TAPause:
+				test.Pauses = append(test.Pauses, Span{Start: evt.Time})
+			case TACont:
+				if len(test.Pauses) > 0 {
+					test.Pauses[len(test.Pauses)-1].End = evt.Time
 				}
 			}
 			if status := FinalTestStatusFromAction(evt.Action); status != nil {
-				test := testResultForPackage[evt.Package][evt.Test]
 				test.TestResult = *status
 				test.Duration = time.Duration(float64(time.Second) * evt.ElapsedSec)
 				switch *status {
@@ -191,7 +366,6 @@ func ParseTestJson(in io.Reader) (result Result, err error) {
 			}
 		}
 	}
-	result.Tests = result.Skipped + result.Failed + result.Passed
 	result.PackageResult = make([]PackageResult, 0, len(packageResult))
 	for _, val := range packageResult {
 		if val.PackageResult == FTPSSkip {
@@ -199,23 +373,32 @@ func ParseTestJson(in io.Reader) (result Result, err error) {
 			continue
 		}
 		res := *val
+		// Without start event, the first event is a test which starts after the package was built and initialized
+		if start := res.Ended.Add(-res.Duration); !started[string(val.Name)] && !res.Ended.IsZero() && start.Before(res.Started) {
+			res.Started = start
+		}
+		res.Coverage = parseCoverage(res.Output)
+		if output, ok := buildOutput[string(val.Name)]; ok {
+			res.Output = append(output, res.Output...)
+		}
 		tests := testResultForPackage[string(val.Name)]
 		res.Tests = make([]TestResult, 0, len(tests))
 		for _, test := range tests {
+			if test.TestResult == FTSFail {
+				test.Assertions = ParseAssertions(test.Output)
+			}
+			if test.TestResult == FTSIncomplete {
+				result.Incomplete++
+			}
 			res.Tests = append(res.Tests, *test)
 			if test.TestResult == FTSPass || test.TestResult == FTPSSkip {
 				res.Succeeded++
 			}
 		}
-		sort.Slice(res.Tests, func(i, j int) bool {
-			return !IsLess(res.Tests[i].TestResult, res.Tests[j].TestResult, res.Tests[i].Duration, res.Tests[j].Duration)
-		})
 		result.PackageResult = append(result.PackageResult, res)
 	}
-	sort.Slice(result.PackageResult, func(i, j int) bool {
-		return !IsLess(result.PackageResult[i].PackageResult, result.PackageResult[j].PackageResult,
-			result.PackageResult[i].Duration, result.PackageResult[j].Duration)
-	})
+	result.Sort(SortByStatus)
+	result.Tests = result.Skipped + result.Failed + result.Passed + result.Incomplete
 	return result, nil
 }
 
@@ -96,6 +96,48 @@ Total: 19 ✔️ Passed: 12 ⏩ Skipped: 3 ❌ Failed: 4 ⏱️ Duration: 2m4s
         
 ⏩ t1 1s  
 </details>
+`,
+		},
+		{report.Result{
+			Tests:  1,
+			Failed: 1,
+			PackageResult: []report.PackageResult{
+				{
+					Name:          "p1",
+					PackageResult: report.FTSFail,
+					Tests: []report.TestResult{
+						{
+							Name:       "t1",
+							TestResult: report.FTSFail,
+							Assertions: []report.Assertion{{Trace: "a_test.go:1", Message: "Not equal", Expected: "1", Actual: "2"}},
+							Output:     []report.OutputLine{{Text: "log"}},
+						},
+					},
+				},
+			},
+		},
+			`# Test Report
+
+Total: 1 ✔️ Passed: 0 ⏩ Skipped: 0 ❌ Failed: 1 ⏱️ Duration: 0s
+
+<details>
+    <summary>❌ 0/1 <b>p1</b> 0s</summary>
+        <blockquote>
+            <details>
+                <summary>❌ t1 0s</summary><blockquote>
+
+a\_test.go:1: Not&nbsp;equal
+
+` + "```diff\n--- Expected\n+++ Actual\n-1\n+2\n```" + `
+
+` + "`00:00:00.000`" + ` log</blockquote>
+</details></blockquote>
+
+</details>
+
+## 🔁 Rerun Failed Tests
+
+` + "```sh\ngo test p1 -run '^t1$'\n```" + `
 `,
 		},
 	}
@@ -166,9 +208,13 @@ func TestParseTestJson(t *testing.T) {
 				Duration:      1117000000,
 				PackageResult: report.FTSPass,
 				Succeeded:     1,
+				Started:       timeObj.Add(-1117 * time.Millisecond),
+				Ended:         timeObj,
 				Tests: []report.TestResult{
 					{Name: "TestIsLess",
 						TestResult: report.FTSPass,
+						Started:    timeObj,
+						Ended:      timeObj,
 						Output: []report.OutputLine{
 							{Time: timeObj},
 							{Time: timeObj},
@@ -223,3 +269,78 @@ func TestPackageResultString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTestJson_Incomplete(t *testing.T) {
+	res, err := report.ParseTestJson(strings.NewReader(`{"Action":"run","Package":"foo","Test":"TestTimeout"}
+{"Action":"output","Package":"foo","Test":"TestTimeout","Output":"panic: test timed out after 1s\n"}
+{"Action":"run","Package":"foo","Test":"TestOk"}
+{"Action":"pass","Package":"foo","Test":"TestOk"}
+{"Action":"fail","Package":"foo","Elapsed":1}
+`))
+	require.Nil(t, err)
+
+	assert.Equal(t, uint(2), res.Tests)
+	assert.Equal(t, uint(1), res.Incomplete)
+	assert.Equal(t, uint(1), res.Passed)
+	require.Len(t, res.PackageResult, 1)
+	assert.Equal(t, 1, res.PackageResult[0].Succeeded)
+	assert.Equal(t, "TestTimeout", res.PackageResult[0].Tests[0].Name)
+	assert.Equal(t, report.FTSIncomplete, res.PackageResult[0].Tests[0].TestResult)
+	assert.True(t, res.PackageResult[0].Failed())
+}
+
+func TestParseTestJson_BuildFailure(t *testing.T) {
+	res, err := report.ParseTestJson(strings.NewReader(`{"ImportPath":"example.com/bad [example.com/bad.test]","Action":"build-output","Output":"# example.com/bad [example.com/bad.test]\n"}
+{"ImportPath":"example.com/bad [example.com/bad.test]","Action":"build-output","Output":"bad/bad.go:3:23: undefined: x\n"}
+{"ImportPath":"example.com/bad [example.com/bad.test]","Action":"build-fail"}
+{"Action":"start","Package":"example.com/bad"}
+{"Action":"output","Package":"example.com/bad","Output":"FAIL\texample.com/bad [build failed]\n"}
+{"Action":"fail","Package":"example.com/bad","Elapsed":0,"FailedBuild":"example.com/bad [example.com/bad.test]"}
+`))
+	require.Nil(t, err)
+
+	require.Len(t, res.PackageResult, 1)
+	pack := res.PackageResult[0]
+	assert.Equal(t, report.PackageName("example.com/bad"), pack.Name)
+	assert.True(t, pack.Failed())
+	require.Len(t, pack.Output, 3)
+	assert.Equal(t, "# example.com/bad [example.com/bad.test]\n", pack.Output[0].Text)
+	assert.Equal(t, "bad/bad.go:3:23: undefined: x\n", pack.Output[1].Text)
+	assert.Equal(t, "FAIL\texample.com/bad [build failed]\n", pack.Output[2].Text)
+}
+
+func TestParseTestJson_ExternalTestBuildFailure(t *testing.T) {
+	// Output of go test -json for a package whose external test package example.com/bfx/a_test does not compile
+	res, err := report.ParseTestJson(strings.NewReader(`{"ImportPath":"example.com/bfx/a_test [example.com/bfx/a.test]","Action":"build-output","Output":"# example.com/bfx/a_test [example.com/bfx/a.test]\n"}
+{"ImportPath":"example.com/bfx/a_test [example.com/bfx/a.test]","Action":"build-output","Output":"a/a_test.go:5:28: undefined: undefined\n"}
+{"ImportPath":"example.com/bfx/a_test [example.com/bfx/a.test]","Action":"build-fail"}
+{"Time":"2026-10-19T10:29:50.793001073Z","Action":"start","Package":"example.com/bfx/a"}
+{"Time":"2026-10-19T10:29:50.793170932Z","Action":"output","Package":"example.com/bfx/a","Output":"FAIL\texample.com/bfx/a [build failed]\n","OutputType":"frame"}
+{"Time":"2026-10-19T10:29:50.79319048Z","Action":"fail","Package":"example.com/bfx/a","Elapsed":0,"FailedBuild":"example.com/bfx/a_test [example.com/bfx/a.test]"}
+`))
+	require.Nil(t, err)
+
+	require.Len(t, res.PackageResult, 1)
+	pack := res.PackageResult[0]
+	assert.Equal(t, report.PackageName("example.com/bfx/a"), pack.Name)
+	assert.True(t, pack.Failed())
+	require.Len(t, pack.Output, 3)
+	assert.Equal(t, "a/a_test.go:5:28: undefined: undefined\n", pack.Output[1].Text)
+}
+
+func TestBuildPackage(t *testing.T) {
+	var suite = []struct {
+		importPath string
+		pack       string
+	}{
+		{"example.com/a", "example.com/a"},
+		{"example.com/a.test", "example.com/a"},
+		{"example.com/a [example.com/a.test]", "example.com/a"},
+		{"example.com/a_test [example.com/a.test]", "example.com/a"},
+	}
+	for _, s := range suite {
+		t.Run(s.importPath, func(t *testing.T) {
+			assert.Equal(t, s.pack, report.BuildPackage(s.importPath))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// Span is the period between two events.
+type Span struct {
+	Start time.Time
+	End   time.Time // zero if the period did not end
+}
+
+// track extends the period from started to ended so that it contains the time.
+func track(started, ended *time.Time, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if started.IsZero() || t.Before(*started) {
+		*started = t
+	}
+	if t.After(*ended) {
+		*ended = t
+	}
+}
+
+// Paused is the total time the parallel test waited for other tests. Pauses which did not
+// end are counted until the last event of the test.
+func (t TestResult) Paused() (paused time.Duration) {
+	for _, pause := range t.Pauses {
+		end := pause.End
+		if end.IsZero() {
+			end = t.Ended
+		}
+		paused += end.Sub(pause.Start)
+	}
+	return paused
+}
+
+// Span returns the period from the first to the last event of all packages.
+func (r Result) Span() (span Span) {
+	for _, pack := range r.PackageResult {
+		track(&span.Start, &span.End, pack.Started)
+		track(&span.Start, &span.End, pack.Ended)
+	}
+	return span
+}
+
+// WallTime is the time from the first to the last event of the run. Unlike the Duration, packages
+// which ran in parallel are not counted multiple times. Zero if the events have no timestamps.
+func (r Result) WallTime() time.Duration {
+	span := r.Span()
+	return span.End.Sub(span.Start)
+}
+
+// CPUTime is the sum of the elapsed times of all packages.
+func (r Result) CPUTime() (sum time.Duration) {
+	for _, pack := range r.PackageResult {
+		sum += pack.Duration
+	}
+	return sum
+}
+
+// Parallelism is the average number of packages which ran at the same time. Zero if the
+// events have no timestamps.
+func (r Result) Parallelism() float64 {
+	wallTime := r.WallTime()
+	if wallTime <= 0 {
+		return 0
+	}
+	return float64(r.CPUTime()) / float64(wallTime)
+}
+
+// CriticalPath returns the chain of packages which determined the wall time in the order in which
+// they ran. The chain starts with the package which ended last. Its predecessor is the package which
+// ended last before it started, because go test starts a package as soon as another one is done.
+func (r Result) CriticalPath() (path []PackageResult) {
+	current := -1
+	for idx, pack := range r.PackageResult {
+		if !pack.Ended.IsZero() && (current < 0 || pack.Ended.After(r.PackageResult[current].Ended)) {
+			current = idx
+		}
+	}
+	for current >= 0 {
+		path = append(path, r.PackageResult[current])
+		start := r.PackageResult[current].Started
+		current = -1
+		for idx, pack := range r.PackageResult {
+			if pack.Ended.IsZero() || pack.Ended.After(start) || !pack.Started.Before(start) {
+				continue
+			}
+			if current < 0 || pack.Ended.After(r.PackageResult[current].Ended) {
+				current = idx
+			}
+		}
+	}
+	reverse(path)
+	return path
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// TimelineEntry is a package or test with its start and end relative to the start of the run.
+type TimelineEntry struct {
+	Package  PackageName
+	Test     string // empty for packages
+	Status   FinalTestStatus
+	Start    time.Duration
+	End      time.Duration
+	Paused   time.Duration
+	Lane     int             // packages which ran at the same time are on different lanes
+	Critical bool            // the package is on the critical path
+	Tests    []TimelineEntry // tests of the package ordered by their start
+}
+
+// Duration is the time from the first to the last event of the entry.
+func (e TimelineEntry) Duration() time.Duration {
+	return e.End - e.Start
+}
+
+// Timeline returns the packages with their tests ordered by their start. Packages and tests
+// without timestamps are omitted.
+func (r Result) Timeline() (timeline []TimelineEntry) {
+	start := r.Span().Start
+	critical := make(map[PackageName]bool)
+	for _, pack := range r.CriticalPath() {
+		critical[pack.Name] = true
+	}
+	for _, pack := range r.PackageResult {
+		if pack.Started.IsZero() {
+			continue
+		}
+		entry := TimelineEntry{
+			Package:  pack.Name,
+			Status:   pack.PackageResult,
+			Start:    pack.Started.Sub(start),
+			End:      pack.Ended.Sub(start),
+			Critical: critical[pack.Name],
+		}
+		for _, test := range pack.Tests {
+			if test.Started.IsZero() {
+				continue
+			}
+			entry.Tests = append(entry.Tests, TimelineEntry{
+				Package: pack.Name,
+				Test:    test.Name,
+				Status:  test.TestResult,
+				Start:   test.Started.Sub(start),
+				End:     test.Ended.Sub(start),
+				Paused:  test.Paused(),
+			})
+		}
+		sortTimeline(entry.Tests)
+		timeline = append(timeline, entry)
+	}
+	sortTimeline(timeline)
+
+	var laneEnds []time.Duration
+	for idx := range timeline {
+		entry := &timeline[idx]
+		entry.Lane = len(laneEnds)
+		for lane, end := range laneEnds {
+			if end <= entry.Start {
+				entry.Lane = lane
+				break
+			}
+		}
+		if entry.Lane == len(laneEnds) {
+			laneEnds = append(laneEnds, entry.End)
+		} else {
+			laneEnds[entry.Lane] = entry.End
+		}
+	}
+	return timeline
+}
+
+func sortTimeline(entries []TimelineEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Start != entries[j].Start {
+			return entries[i].Start < entries[j].Start
+		}
+		if entries[i].Package != entries[j].Package {
+			return entries[i].Package < entries[j].Package
+		}
+		return compareNames(entries[i].Test, entries[j].Test) < 0
+	})
+}
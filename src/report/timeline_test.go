@@ -0,0 +1,97 @@
+package report_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Package a runs from 0s to 4s and b from 1s to 2s. Package c starts after a ended.
+const timelineJson = `{"Time":"2024-01-01T10:00:00Z","Action":"start","Package":"a"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"a","Test":"TestP"}
+{"Time":"2024-01-01T10:00:01Z","Action":"start","Package":"b"}
+{"Time":"2024-01-01T10:00:01Z","Action":"pause","Package":"a","Test":"TestP"}
+{"Time":"2024-01-01T10:00:01Z","Action":"run","Package":"b","Test":"TestB"}
+{"Time":"2024-01-01T10:00:02Z","Action":"pass","Package":"b","Test":"TestB","Elapsed":1}
+{"Time":"2024-01-01T10:00:02Z","Action":"pass","Package":"b","Elapsed":1}
+{"Time":"2024-01-01T10:00:03Z","Action":"fail","Package":"a","Test":"TestA","Elapsed":3}
+{"Time":"2024-01-01T10:00:03Z","Action":"cont","Package":"a","Test":"TestP"}
+{"Time":"2024-01-01T10:00:04Z","Action":"pass","Package":"a","Test":"TestP","Elapsed":1}
+{"Time":"2024-01-01T10:00:04Z","Action":"fail","Package":"a","Elapsed":4}
+{"Time":"2024-01-01T10:00:05Z","Action":"start","Package":"c"}
+{"Time":"2024-01-01T10:00:05Z","Action":"run","Package":"c","Test":"TestC"}
+{"Time":"2024-01-01T10:00:06Z","Action":"pass","Package":"c","Test":"TestC","Elapsed":1}
+{"Time":"2024-01-01T10:00:06Z","Action":"pass","Package":"c","Elapsed":1}
+`
+
+func TestResultTimeline(t *testing.T) {
+	result, err := report.ParseTestJson(strings.NewReader(timelineJson))
+	require.Nil(t, err)
+
+	assert.Equal(t, 6*time.Second, result.WallTime())
+	assert.Equal(t, 6*time.Second, result.CPUTime())
+	assert.Equal(t, 1.0, result.Parallelism())
+
+	var path []report.PackageName
+	for _, pack := range result.CriticalPath() {
+		path = append(path, pack.Name)
+	}
+	assert.Equal(t, []report.PackageName{"a", "c"}, path)
+
+	timeline := result.Timeline()
+	require.Len(t, timeline, 3)
+	assert.Equal(t, report.TimelineEntry{Package: "b", Status: report.FTSPass, Start: time.Second, End: 2 * time.Second, Lane: 1,
+		Tests: []report.TimelineEntry{{Package: "b", Test: "TestB", Status: report.FTSPass, Start: time.Second, End: 2 * time.Second}},
+	}, timeline[1])
+	assert.Equal(t, report.TimelineEntry{Package: "c", Status: report.FTSPass, Start: 5 * time.Second, End: 6 * time.Second, Critical: true,
+		Tests: []report.TimelineEntry{{Package: "c", Test: "TestC", Status: report.FTSPass, Start: 5 * time.Second, End: 6 * time.Second}},
+	}, timeline[2])
+	assert.Equal(t, report.TimelineEntry{Package: "a", Status: report.FTSFail, End: 4 * time.Second, Critical: true,
+		Tests: []report.TimelineEntry{
+			{Package: "a", Test: "TestA", Status: report.FTSFail, End: 3 * time.Second},
+			{Package: "a", Test: "TestP", Status: report.FTSPass, End: 4 * time.Second, Paused: 2 * time.Second},
+		},
+	}, timeline[0])
+	assert.Equal(t, 4*time.Second, timeline[0].Duration())
+}
+
+func TestResultTimeline_NoStartEvents(t *testing.T) {
+	// go 1.19 and older do not write start events, so the package starts before its first test event
+	result, err := report.ParseTestJson(strings.NewReader(`{"Time":"2024-01-01T10:00:01Z","Action":"run","Package":"a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:02Z","Action":"pass","Package":"a","Test":"TestA","Elapsed":1}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Package":"a","Elapsed":3}
+`))
+	require.Nil(t, err)
+
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), result.PackageResult[0].Started.UTC())
+	assert.Equal(t, 3*time.Second, result.WallTime())
+	assert.Equal(t, 3*time.Second, result.CPUTime())
+	assert.Equal(t, 1.0, result.Parallelism())
+}
+
+func TestResultTimeline_NoTimestamps(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{{Name: "a", Duration: time.Second}}}
+
+	assert.Equal(t, time.Duration(0), result.WallTime())
+	assert.Equal(t, time.Second, result.CPUTime())
+	assert.Equal(t, 0.0, result.Parallelism())
+	assert.Empty(t, result.CriticalPath())
+	assert.Empty(t, result.Timeline())
+}
+
+func TestTestResultPaused(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	test := report.TestResult{
+		Ended: start.Add(10 * time.Second),
+		Pauses: []report.Span{
+			{Start: start, End: start.Add(time.Second)},
+			{Start: start.Add(8 * time.Second)},
+		},
+	}
+	assert.Equal(t, 3*time.Second, test.Paused())
+}
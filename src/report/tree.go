@@ -0,0 +1,124 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// TreeNode is a directory of the package import paths with the aggregated results
+// of all packages below it.
+type TreeNode struct {
+	Name       string // import path segments relative to the parent node
+	Path       string // full import path
+	Module     bool   // the node is the root of a go module
+	Package    *PackageResult
+	Children   []*TreeNode
+	Tests      uint
+	Passed     uint
+	Failed     uint
+	Skipped    uint
+	Incomplete uint
+	Duration   time.Duration
+	Status     FinalTestStatus
+}
+
+// Succeeded is the number of passed and skipped tests.
+func (n TreeNode) Succeeded() uint {
+	return n.Passed + n.Skipped
+}
+
+func (n *TreeNode) child(name, path string) *TreeNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	child := &TreeNode{Name: name, Path: path}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+func (n *TreeNode) add(pack PackageResult) {
+	for _, test := range pack.Tests {
+		switch test.TestResult {
+		case FTSPass:
+			n.Passed++
+		case FTSFail:
+			n.Failed++
+		case FTPSSkip:
+			n.Skipped++
+		case FTSIncomplete:
+			n.Incomplete++
+		}
+	}
+	n.Tests = n.Passed + n.Failed + n.Skipped + n.Incomplete
+	n.Duration += pack.Duration
+	if pack.PackageResult == FTSFail || (pack.PackageResult == FTSPass && n.Status == FTPSSkip) {
+		n.Status = pack.PackageResult
+	}
+}
+
+// collapse merges nodes which only have a single child and are no package or module.
+func (n *TreeNode) collapse() {
+	for idx, child := range n.Children {
+		for child.Package == nil && !child.Module && len(child.Children) == 1 &&
+			!child.Children[0].Module {
+			grandChild := child.Children[0]
+			grandChild.Name = child.Name + "/" + grandChild.Name
+			child = grandChild
+		}
+		child.collapse()
+		n.Children[idx] = child
+	}
+}
+
+// Tree returns the packages ordered in a tree of import path segments. If the result
+// contains multiple Modules, the packages are grouped by module first.
+// Chains of directories without packages are merged into a single node.
+// The order of the packages is kept.
+func (r Result) Tree() *TreeNode {
+	modules := make([]string, 0, len(r.Modules))
+	for _, module := range r.Modules {
+		modules = append(modules, module.Path)
+	}
+	// Prefer the most specific module for nested modules
+	sort.SliceStable(modules, func(i, j int) bool {
+		return len(modules[i]) > len(modules[j])
+	})
+	groupByModule := len(modules) > 1
+
+	root := &TreeNode{}
+	for idx := range r.PackageResult {
+		pack := &r.PackageResult[idx]
+		name := string(pack.Name)
+		node := root
+		node.add(*pack)
+		if groupByModule {
+			for _, module := range modules {
+				if name == module || strings.HasPrefix(name, module+"/") {
+					node = node.child(module, module)
+					node.Module = true
+					node.add(*pack)
+					name = strings.TrimPrefix(strings.TrimPrefix(name, module), "/")
+					break
+				}
+			}
+		}
+		path := strings.TrimSuffix(node.Path, "/")
+		for _, segment := range strings.Split(name, "/") {
+			if segment == "" {
+				continue
+			}
+			if path != "" {
+				path += "/"
+			}
+			path += segment
+			node = node.child(segment, path)
+			node.add(*pack)
+		}
+		node.Package = pack
+	}
+	root.collapse()
+	return root
+}
@@ -0,0 +1,120 @@
+package report_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/gomod"
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pack(name report.PackageName, status report.FinalTestStatus, duration time.Duration, tests ...report.FinalTestStatus) report.PackageResult {
+	res := report.PackageResult{Name: name, PackageResult: status, Duration: duration}
+	for _, test := range tests {
+		res.Tests = append(res.Tests, report.TestResult{Name: "Test", TestResult: test})
+	}
+	return res
+}
+
+type flatNode struct {
+	name      string
+	path      string
+	module    bool
+	isPackage bool
+	children  int
+	tests     uint
+	failed    uint
+	duration  time.Duration
+	status    report.FinalTestStatus
+}
+
+func flatten(node *report.TreeNode) (nodes []flatNode) {
+	for _, child := range node.Children {
+		nodes = append(nodes, flatNode{child.Name, child.Path, child.Module, child.Package != nil, len(child.Children), child.Tests, child.Failed, child.Duration, child.Status})
+		nodes = append(nodes, flatten(child)...)
+	}
+	return nodes
+}
+
+func TestResultTree(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		pack("github.com/me/repo/api/users", report.FTSFail, time.Second, report.FTSFail, report.FTSPass),
+		pack("github.com/me/repo/api", report.FTSPass, time.Second, report.FTSPass),
+		pack("github.com/me/repo/internal/deep/storage", report.FTSPass, time.Second, report.FTPSSkip),
+	}}
+
+	root := result.Tree()
+
+	assert.Equal(t, uint(4), root.Tests)
+	assert.Equal(t, uint(3), root.Succeeded())
+	assert.Equal(t, report.FTSFail, root.Status)
+	assert.Equal(t, []flatNode{
+		{"github.com/me/repo", "github.com/me/repo", false, false, 2, 4, 1, time.Second * 3, report.FTSFail},
+		{"api", "github.com/me/repo/api", false, true, 1, 3, 1, time.Second * 2, report.FTSFail},
+		{"users", "github.com/me/repo/api/users", false, true, 0, 2, 1, time.Second, report.FTSFail},
+		{"internal/deep/storage", "github.com/me/repo/internal/deep/storage", false, true, 0, 1, 0, time.Second, report.FTSPass},
+	}, flatten(root))
+	assert.Equal(t, &result.PackageResult[1], root.Children[0].Children[0].Package)
+}
+
+func TestResultTree_Modules(t *testing.T) {
+	result := report.Result{
+		Modules: []gomod.Module{{Path: "example.com/repo"}, {Path: "example.com/repo/tools"}},
+		PackageResult: []report.PackageResult{
+			pack("example.com/repo/tools/gen", report.FTSPass, time.Second, report.FTSPass),
+			pack("example.com/repo", report.FTSPass, time.Second, report.FTSPass),
+			pack("example.com/repo/pkg/a", report.FTPSSkip, time.Second, report.FTPSSkip),
+			pack("other.com/x", report.FTSPass, time.Second, report.FTSPass),
+		},
+	}
+
+	assert.Equal(t, []flatNode{
+		{"example.com/repo/tools", "example.com/repo/tools", true, false, 1, 1, 0, time.Second, report.FTSPass},
+		{"gen", "example.com/repo/tools/gen", false, true, 0, 1, 0, time.Second, report.FTSPass},
+		{"example.com/repo", "example.com/repo", true, true, 1, 2, 0, time.Second * 2, report.FTSPass},
+		{"pkg/a", "example.com/repo/pkg/a", false, true, 0, 1, 0, time.Second, report.FTPSSkip},
+		{"other.com/x", "other.com/x", false, true, 0, 1, 0, time.Second, report.FTSPass},
+	}, flatten(result.Tree()))
+}
+
+func TestGetTemplate_Builtin(t *testing.T) {
+	for name := range report.BuiltinTemplates {
+		t.Run(name, func(t *testing.T) {
+			temp, err := report.GetTemplate(name)
+			require.Nil(t, err)
+			buff := bytes.NewBuffer(nil)
+			result := report.Result{Tests: 1, Passed: 1, PackageResult: []report.PackageResult{
+				pack("example.com/repo/api", report.FTSPass, time.Second, report.FTSPass),
+			}}
+			require.Nil(t, report.CreateReport(result, buff, temp))
+			assert.Contains(t, buff.String(), "<b>")
+			assert.Contains(t, buff.String(), "api")
+		})
+	}
+	_, err := report.GetTemplate("unknown-template-file")
+	assert.NotNil(t, err)
+}
+
+func TestGetTemplate_FileBeforeBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "md"), []byte("custom {{.Tests}}"), 0o644))
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	temp, err := report.GetTemplate("md")
+	require.Nil(t, err)
+	buff := bytes.NewBuffer(nil)
+	require.Nil(t, report.CreateReport(report.Result{Tests: 1}, buff, temp))
+	assert.Equal(t, "custom 1", buff.String())
+
+	temp, err = report.GetTemplate("tree")
+	require.Nil(t, err)
+	assert.NotNil(t, temp)
+}
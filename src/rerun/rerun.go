@@ -0,0 +1,105 @@
+package rerun
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/becheran/go-testreport/src/report"
+)
+
+// Runner runs the go command with the arguments and returns the json test output.
+type Runner func(args []string) ([]byte, error)
+
+// GoTest runs the go tool of the PATH. Failed tests are no error.
+func GoTest(args []string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) > 0 {
+		// go test returns a non zero exit code if a test failed
+		return out, nil
+	}
+	return out, err
+}
+
+// failingTests returns the names of the tests whose last attempt failed without the
+// parent tests which only failed because of a subtest.
+func failingTests(pack report.PackageResult) (names []string) {
+	for _, test := range pack.Tests {
+		if test.TestResult != report.FTSFail || test.LastStatus() != report.FTSFail {
+			continue
+		}
+		hasFailingSubtest := false
+		for _, other := range pack.Tests {
+			if other.TestResult == report.FTSFail && other.LastStatus() == report.FTSFail && strings.HasPrefix(other.Name, test.Name+"/") {
+				hasFailingSubtest = true
+				break
+			}
+		}
+		if !hasFailingSubtest {
+			names = append(names, test.Name)
+		}
+	}
+	return names
+}
+
+// Rerun runs the failed tests of all packages again until they pass or maxAttempts reruns were done.
+// The results of the reruns are added as attempts to the failed tests. Packages which failed without
+// failed tests are not rerun.
+func Rerun(result *report.Result, maxAttempts int, run Runner) error {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		rerunAny := false
+		for pIdx := range result.PackageResult {
+			pack := &result.PackageResult[pIdx]
+			names := failingTests(*pack)
+			if len(names) == 0 {
+				continue
+			}
+			rerunAny = true
+			out, err := run([]string{"test", "-json", "-count=1", pack.Target(), "-run", report.RunRegex(names)})
+			if err != nil {
+				return fmt.Errorf("failed to rerun tests of package %s. %s", pack.Name, err)
+			}
+			rerunResult, err := report.ParseTestJson(bytes.NewReader(out))
+			if err != nil {
+				return fmt.Errorf("failed to parse rerun of package %s. %s", pack.Name, err)
+			}
+			merge(pack, rerunResult)
+		}
+		if !rerunAny {
+			break
+		}
+	}
+	result.UpdateTotals()
+	return nil
+}
+
+// merge adds the rerun results as attempt to all failing tests of the package. Tests which are
+// missing in the rerun are added as incomplete attempt.
+func merge(pack *report.PackageResult, rerunResult report.Result) {
+	rerunTests := make(map[string]report.TestResult)
+	for _, rerunPack := range rerunResult.PackageResult {
+		if rerunPack.Name != pack.Name {
+			continue
+		}
+		for _, test := range rerunPack.Tests {
+			rerunTests[test.Name] = test
+		}
+	}
+	for tIdx := range pack.Tests {
+		test := &pack.Tests[tIdx]
+		if test.TestResult != report.FTSFail || test.LastStatus() != report.FTSFail {
+			continue
+		}
+		attempt := report.Attempt{TestResult: report.FTSIncomplete}
+		if rerunTest, ok := rerunTests[test.Name]; ok {
+			attempt = report.Attempt{TestResult: rerunTest.TestResult, Duration: rerunTest.Duration, Started: rerunTest.Started, Output: rerunTest.Output}
+		}
+		test.Attempts = append(test.Attempts, attempt)
+	}
+}
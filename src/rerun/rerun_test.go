@@ -0,0 +1,98 @@
+package rerun_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becheran/go-testreport/src/report"
+	"github.com/becheran/go-testreport/src/rerun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func events(pack string, results map[string]string) []byte {
+	out := strings.Builder{}
+	for test, action := range results {
+		fmt.Fprintf(&out, `{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"%s","Test":"%s"}`+"\n", pack, test)
+		fmt.Fprintf(&out, `{"Action":"%s","Package":"%s","Test":"%s","Elapsed":0.5}`+"\n", action, pack, test)
+	}
+	fmt.Fprintf(&out, `{"Action":"fail","Package":"%s","Elapsed":1}`+"\n", pack)
+	return []byte(out.String())
+}
+
+func TestRerun(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", Dir: "a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestFlaky", TestResult: report.FTSFail},
+			{Name: "TestParent", TestResult: report.FTSFail},
+			{Name: "TestParent/sub", TestResult: report.FTSFail},
+			{Name: "TestPass", TestResult: report.FTSPass},
+		}},
+		{Name: "example.com/build", PackageResult: report.FTSFail},
+		{Name: "example.com/ok", PackageResult: report.FTSPass},
+	}}
+	var calls [][]string
+	runs := []map[string]string{
+		{"TestFlaky": "fail", "TestParent": "fail", "TestParent/sub": "fail"},
+		{"TestFlaky": "pass"},
+	}
+	runner := func(args []string) ([]byte, error) {
+		calls = append(calls, args)
+		return events("example.com/a", runs[len(calls)-1]), nil
+	}
+
+	require.Nil(t, rerun.Rerun(&result, 3, runner))
+
+	assert.Equal(t, [][]string{
+		{"test", "-json", "-count=1", "./a", "-run", "^TestFlaky$|^TestParent$/^sub$"},
+		{"test", "-json", "-count=1", "./a", "-run", "^TestFlaky$|^TestParent$/^sub$"},
+	}, calls, "tests missing in a rerun are incomplete and not run again")
+	tests := result.PackageResult[0].Tests
+	assert.Equal(t, []report.FinalTestStatus{report.FTSFail, report.FTSPass}, statuses(tests[0].Attempts))
+	assert.Equal(t, "flaky", tests[0].Classification())
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), tests[0].Attempts[1].Started.UTC())
+	assert.True(t, tests[2].Attempts[1].Started.IsZero(), "incomplete attempts did not run")
+	assert.Equal(t, []report.FinalTestStatus{report.FTSFail, report.FTSIncomplete}, statuses(tests[2].Attempts))
+	assert.Equal(t, "failed", tests[2].Classification())
+	assert.Empty(t, tests[3].Attempts)
+	assert.Equal(t, uint(1), result.Flaky)
+	assert.True(t, result.PackageResult[0].Failed())
+}
+
+func statuses(attempts []report.Attempt) (res []report.FinalTestStatus) {
+	for _, attempt := range attempts {
+		res = append(res, attempt.TestResult)
+	}
+	return res
+}
+
+func TestRerun_AllFlaky(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{
+			{Name: "TestFlaky", TestResult: report.FTSFail},
+		}},
+	}}
+	calls := 0
+	require.Nil(t, rerun.Rerun(&result, 5, func(args []string) ([]byte, error) {
+		calls++
+		assert.Equal(t, "example.com/a", args[3])
+		return events("example.com/a", map[string]string{"TestFlaky": "pass"}), nil
+	}))
+
+	assert.Equal(t, 1, calls)
+	assert.False(t, result.PackageResult[0].Failed())
+	assert.Equal(t, uint(1), result.Flaky)
+	assert.Equal(t, uint(0), result.PersistentFailures())
+}
+
+func TestRerun_Error(t *testing.T) {
+	result := report.Result{PackageResult: []report.PackageResult{
+		{Name: "example.com/a", PackageResult: report.FTSFail, Tests: []report.TestResult{{Name: "TestA", TestResult: report.FTSFail}}},
+	}}
+	err := rerun.Rerun(&result, 1, func(args []string) ([]byte, error) {
+		return nil, fmt.Errorf("go not found")
+	})
+	assert.NotNil(t, err)
+}